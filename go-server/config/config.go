@@ -3,44 +3,165 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+)
+
+// Modos de autenticação das rotas protegidas
+const (
+	// AuthModeOff desativa a autenticação (comportamento legado, apenas para desenvolvimento)
+	AuthModeOff = "off"
+	// AuthModeMigration exige identidade (JWT ou sessão do Node.js repassada pelo proxy) em operações
+	// de escrita e nas rotas com verificação de papel, mas ainda permite leituras anônimas
+	AuthModeMigration = "migration"
+	// AuthModeEnforced exige identidade em todas as rotas protegidas
+	AuthModeEnforced = "enforced"
 )
 
 // Config contém todas as configurações do aplicativo
 type Config struct {
 	ServerPort      string
 	DatabaseURL     string
-	JWTSecret       string
 	Environment     string
 	LogLevel        string
 	MigrationPhase  bool
 	MigratedRoutes  []string
 	AllowCORS       bool
 	NodeJSProxyPath string
+	// Intervalo mínimo (deslocamento/preparação) entre dois eventos do mesmo voluntário
+	ScheduleBufferMinutes int
+	// Modo de autenticação (AuthModeOff, AuthModeMigration ou AuthModeEnforced)
+	AuthMode string
+	// Segredo compartilhado com o proxy para aceitar sessões do Node.js; vazio desativa o repasse
+	ProxySessionSecret string
+	// Validade do token de acesso (JWT) e do token de atualização que o renova
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLDays   int
+	// Entrega de mensagens aos usuários ("log", "file" ou "smtp") e arquivo usado pelo modo "file"
+	MailSender   string
+	MailFilePath string
+	// Servidor SMTP usado pelo modo "smtp" (sem usuário, não há autenticação) e remetente das mensagens
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	// Intervalo entre as rodadas de envio das notificações externas e tentativas antes de desistir
+	NotificationDispatchIntervalSeconds int
+	NotificationMaxAttempts             int
+	// Provedor de SMS/WhatsApp ("fake" ou "webhook"). O webhook envia no formato "twilio" ou
+	// "whatsapp_cloud" (WhatsApp Business), autenticado com usuário/senha (Basic) ou só token (Bearer).
+	TextProvider        string
+	TextWebhookURL      string
+	TextWebhookFormat   string
+	TextWebhookUsername string
+	TextWebhookPassword string
+	TextSMSFrom         string
+	TextWhatsAppFrom    string
+	// Endereço público do callback de status das mensagens e token que o provedor precisa enviar nele
+	TextStatusCallbackURL   string
+	TextStatusCallbackToken string
+	// Endereço do frontend usado nos links enviados aos usuários
+	AppBaseURL string
+	// Validade dos links de redefinição de senha
+	PasswordResetTTLMinutes int
+	// Permite o cadastro sem convite (sempre com o papel "volunteer")
+	OpenRegistration bool
+	// Validade dos convites
+	InvitationTTLDays int
+	// Falhas de login antes do bloqueio temporário (por nome de usuário e por IP) e duração do bloqueio
+	LoginMaxFailures    int
+	LoginIPMaxFailures  int
+	LoginLockoutMinutes int
+	// Endereços (IPs ou CIDRs) dos proxies cujo X-Forwarded-For é aceito como IP do cliente; vazio
+	// (servidor exposto diretamente) usa o endereço da conexão
+	TrustedProxies []string
+	// Chave privada PEM (RSA ou Ed25519) que assina os tokens e chaves públicas anteriores ainda aceitas
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+	// Serviços acompanhados por um parceiro experiente antes que o trainee possa ser graduado
+	TraineeGraduationThreshold int
+	// Antecedências padrão (em horas) dos lembretes de escala, para times sem configuração própria,
+	// e intervalo entre as verificações de lembretes
+	ReminderLeadHours       []int
+	ReminderIntervalMinutes int
 }
 
 // LoadConfig carrega a configuração a partir de variáveis de ambiente
 func LoadConfig() Config {
 	config := Config{
-		ServerPort:      getEnv("PORT", "5001"),
-		DatabaseURL:     getEnv("DATABASE_URL", ""),
-		JWTSecret:       getEnv("JWT_SECRET", "volunteer-scheduler-secret"),
-		Environment:     getEnv("GO_ENV", "development"),
-		LogLevel:        getEnv("LOG_LEVEL", "info"),
-		MigrationPhase:  getEnvAsBool("MIGRATION_PHASE", true),
-		AllowCORS:       getEnvAsBool("ALLOW_CORS", true),
-		NodeJSProxyPath: getEnv("NODEJS_PROXY_URL", "http://localhost:5000"),
+		ServerPort:                          getEnv("PORT", "5001"),
+		DatabaseURL:                         getEnv("DATABASE_URL", ""),
+		Environment:                         getEnv("GO_ENV", "development"),
+		LogLevel:                            getEnv("LOG_LEVEL", "info"),
+		MigrationPhase:                      getEnvAsBool("MIGRATION_PHASE", true),
+		AllowCORS:                           getEnvAsBool("ALLOW_CORS", true),
+		NodeJSProxyPath:                     getEnv("NODEJS_PROXY_URL", "http://localhost:5000"),
+		ScheduleBufferMinutes:               getEnvAsInt("SCHEDULE_BUFFER_MINUTES", 30),
+		ProxySessionSecret:                  getEnv("PROXY_SESSION_SECRET", ""),
+		AccessTokenTTLMinutes:               getEnvAsInt("ACCESS_TOKEN_TTL_MINUTES", 15),
+		RefreshTokenTTLDays:                 getEnvAsInt("REFRESH_TOKEN_TTL_DAYS", 30),
+		MailSender:                          getEnv("MAIL_SENDER", "log"),
+		MailFilePath:                        getEnv("MAIL_FILE_PATH", "mail.log"),
+		SMTPHost:                            getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:                            getEnv("SMTP_PORT", "587"),
+		SMTPUsername:                        getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:                        getEnv("SMTP_PASSWORD", ""),
+		MailFrom:                            getEnv("MAIL_FROM", "Escala de Voluntários <nao-responda@localhost>"),
+		NotificationDispatchIntervalSeconds: getEnvAsInt("NOTIFICATION_DISPATCH_INTERVAL_SECONDS", 30),
+		NotificationMaxAttempts:             getEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+		TextProvider:                        getEnv("TEXT_PROVIDER", "fake"),
+		TextWebhookURL:                      getEnv("TEXT_WEBHOOK_URL", ""),
+		TextWebhookFormat:                   getEnv("TEXT_WEBHOOK_FORMAT", "twilio"),
+		TextWebhookUsername:                 getEnv("TEXT_WEBHOOK_USERNAME", ""),
+		TextWebhookPassword:                 getEnv("TEXT_WEBHOOK_PASSWORD", ""),
+		TextSMSFrom:                         getEnv("TEXT_SMS_FROM", ""),
+		TextWhatsAppFrom:                    getEnv("TEXT_WHATSAPP_FROM", ""),
+		TextStatusCallbackURL:               getEnv("TEXT_STATUS_CALLBACK_URL", ""),
+		TextStatusCallbackToken:             getEnv("TEXT_STATUS_CALLBACK_TOKEN", ""),
+		AppBaseURL:                          getEnv("APP_BASE_URL", "http://localhost:5000"),
+		PasswordResetTTLMinutes:             getEnvAsInt("PASSWORD_RESET_TTL_MINUTES", 60),
+		OpenRegistration:                    getEnvAsBool("OPEN_REGISTRATION", true),
+		InvitationTTLDays:                   getEnvAsInt("INVITATION_TTL_DAYS", 7),
+		LoginMaxFailures:                    getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:                  getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginLockoutMinutes:                 getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		TrustedProxies:                      getEnvAsList("TRUSTED_PROXIES"),
+		JWTSigningKeyFile:                   getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles:             getEnvAsList("JWT_VERIFICATION_KEY_FILES"),
+		TraineeGraduationThreshold:          getEnvAsInt("TRAINEE_GRADUATION_THRESHOLD", 4),
+		ReminderLeadHours:                   getEnvAsIntList("REMINDER_LEAD_HOURS", []int{24}),
+		ReminderIntervalMinutes:             getEnvAsInt("REMINDER_INTERVAL_MINUTES", 5),
+	}
+
+	// Durante a migração o padrão é o modo de transição; depois dela, autenticação obrigatória
+	defaultAuthMode := AuthModeEnforced
+	if config.MigrationPhase {
+		defaultAuthMode = AuthModeMigration
+	}
+	config.AuthMode = getEnv("AUTH_MODE", defaultAuthMode)
+	if config.AuthMode != AuthModeOff && config.AuthMode != AuthModeMigration && config.AuthMode != AuthModeEnforced {
+		// Valor desconhecido: optar pelo modo mais seguro
+		config.AuthMode = AuthModeEnforced
 	}
 
 	// Definir rotas migradas padrão
 	config.MigratedRoutes = []string{
 		"/api/health",
+		"/api/users",
 		"/api/teams",
 		"/api/roles",
 		"/api/volunteers",
 		"/api/events",
+		"/api/event-series",
 		"/api/schedules",
+		"/api/availability-rules",
+		"/api/staffing-requirements",
+		"/api/coverage",
 		"/api/swap-requests",
 		"/api/notifications",
+		"/api/calendar",
+		"/api/invitations",
+		"/.well-known/jwks.json",
 	}
 
 	// Substituir rotas migradas se definido em variável de ambiente
@@ -52,6 +173,11 @@ func LoadConfig() Config {
 	return config
 }
 
+// IsProduction indica se o servidor roda em produção (GO_ENV ou NODE_ENV igual a "production")
+func (c Config) IsProduction() bool {
+	return c.Environment == "production" || os.Getenv("NODE_ENV") == "production"
+}
+
 // getEnv recupera uma variável de ambiente ou retorna um valor padrão
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -60,6 +186,34 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsList recupera uma variável de ambiente com valores separados por vírgula
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsIntList recupera uma variável de ambiente com inteiros separados por vírgula; valores
+// inválidos fazem valer o padrão
+func getEnvAsIntList(key string, defaultValue []int) []int {
+	var values []int
+	for _, valueStr := range getEnvAsList(key) {
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 // getEnvAsBool recupera uma variável de ambiente como booleano
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if valueStr, exists := os.LookupEnv(key); exists {
@@ -80,4 +234,4 @@ func getEnvAsInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"volunteer-scheduler/db"
+)
+
+// Ações registradas no log de auditoria
+const (
+	auditLoginLockout = "login_lockout"
+	auditLoginUnlock  = "login_unlock"
+	// Fim do período de trainee de um voluntário
+	auditTraineeGraduation = "trainee_graduation"
+)
+
+// recordAuditLog grava uma entrada de auditoria com o autor (usuário logado, se houver) e o IP da requisição.
+// Falhas são apenas registradas no log para não interromper a operação auditada.
+func recordAuditLog(c *gin.Context, action string, targetUserID *int, details string) {
+	var actorID interface{}
+	if userID, exists := c.Get("userID"); exists {
+		actorID = userID
+	}
+
+	_, err := db.DB.Exec(context.Background(),
+		`INSERT INTO audit_logs (action, actor_id, target_user_id, ip_address, details)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		action, actorID, targetUserID, c.ClientIP(), details)
+	if err != nil {
+		log.Printf("Erro ao registrar auditoria (%s): %v", action, err)
+	}
+}
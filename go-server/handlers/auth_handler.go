@@ -2,15 +2,37 @@ package handlers
 
 import (
 	"context"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
 	"golang.org/x/crypto/bcrypt"
 	"volunteer-scheduler/db"
 	"volunteer-scheduler/models"
 	"volunteer-scheduler/utils"
 )
 
+// LoginLimiter protege o login contra tentativas de adivinhar senhas (criado em main.go com a configuração)
+var LoginLimiter *utils.LoginLimiter
+
+// StartLoginAttemptSweeper remove periodicamente de LoginLimiter as tentativas de login expiradas, para que
+// nomes de usuário e IPs que não voltam a tentar não se acumulem na memória
+func StartLoginAttemptSweeper(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			if removed := LoginLimiter.Sweep(); removed > 0 {
+				log.Printf("Tentativas de login expiradas removidas: %d", removed)
+			}
+		}
+	}()
+}
+
 // Login autentica um usuário e retorna um token JWT
 func Login(c *gin.Context) {
 	var loginRequest models.LoginRequest
@@ -24,13 +46,20 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Respeitar a espera entre falhas e o bloqueio temporário
+	if wait := LoginLimiter.Check(loginRequest.Username, c.ClientIP()); wait > 0 {
+		respondLoginThrottled(c, wait)
+		return
+	}
+
 	// Obter usuário pelo nome de usuário
 	var user models.User
 	err := db.DB.QueryRow(context.Background(),
-		"SELECT id, username, password, name, email, role, created_at FROM users WHERE username = $1",
-		loginRequest.Username).Scan(&user.ID, &user.Username, &user.Password, &user.Name, &user.Email, &user.Role, &user.CreatedAt)
+		"SELECT id, username, password, name, email, role, active, created_at FROM users WHERE username = $1",
+		loginRequest.Username).Scan(&user.ID, &user.Username, &user.Password, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
 
 	if err != nil {
+		recordLoginFailure(c, loginRequest.Username, nil)
 		c.JSON(http.StatusUnauthorized, models.ApiResponse{
 			Success: false,
 			Error:   "Usuário ou senha incorretos",
@@ -41,15 +70,40 @@ func Login(c *gin.Context) {
 	// Verificar senha
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password))
 	if err != nil {
+		recordLoginFailure(c, loginRequest.Username, &user.ID)
 		c.JSON(http.StatusUnauthorized, models.ApiResponse{
 			Success: false,
 			Error:   "Usuário ou senha incorretos",
 		})
 		return
 	}
+	LoginLimiter.RecordSuccess(loginRequest.Username)
 
-	// Gerar token JWT
-	token, err := utils.GenerateToken(user)
+	// Usuários desativados não podem entrar
+	if !user.Active {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário desativado",
+		})
+		return
+	}
+
+	// Remover senha da resposta
+	user.Password = ""
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Abrir uma nova sessão com token de acesso e token de atualização
+	sessionID, err := utils.GenerateRandomToken(16)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -58,21 +112,211 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Remover senha da resposta
-	user.Password = ""
+	response, _, err := issueTokens(tx, user, sessionID, c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar token",
+		})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	// Retornar resposta com tokens e dados do usuário
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// RefreshToken troca um token de atualização válido por um novo par de tokens (rotação).
+// A reutilização de um token já trocado indica vazamento e encerra a sessão inteira.
+func RefreshToken(c *gin.Context) {
+	var refreshRequest models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&refreshRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var tokenID, userID int
+	var sessionID string
+	var revoked, expired bool
+	err = tx.QueryRow(context.Background(),
+		`SELECT id, user_id, session_id, revoked_at IS NOT NULL, expires_at <= NOW()
+		 FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`,
+		utils.HashToken(refreshRequest.RefreshToken)).Scan(&tokenID, &userID, &sessionID, &revoked, &expired)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Token de atualização inválido",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar token de atualização",
+		})
+		return
+	}
+
+	if revoked {
+		// Token já trocado ou sessão encerrada: revogar o que restar da sessão
+		if err := revokeSession(tx, sessionID); err == nil {
+			tx.Commit(context.Background())
+		}
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Sessão encerrada",
+		})
+		return
+	}
+
+	if expired {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Sessão expirada",
+		})
+		return
+	}
+
+	var user models.User
+	err = tx.QueryRow(context.Background(),
+		"SELECT id, username, name, email, role, active, created_at FROM users WHERE id = $1", userID).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+	if err != nil || !user.Active {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário desativado",
+		})
+		return
+	}
+
+	response, newTokenID, err := issueTokens(tx, user, sessionID, c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar token",
+		})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(),
+		"UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by_id = $1 WHERE id = $2", newTokenID, tokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar token de atualização",
+		})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// Logout encerra a sessão do token de atualização informado neste dispositivo
+func Logout(c *gin.Context) {
+	var logoutRequest models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&logoutRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	_, err := db.DB.Exec(context.Background(),
+		`UPDATE refresh_tokens SET revoked_at = NOW()
+		 WHERE session_id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)
+		 AND revoked_at IS NULL`,
+		utils.HashToken(logoutRequest.RefreshToken))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao encerrar sessão",
+		})
+		return
+	}
 
-	// Retornar resposta com token e dados do usuário
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
-		Data: models.LoginResponse{
-			Token: token,
-			User:  user,
-		},
+		Message: "Sessão encerrada com sucesso",
 	})
 }
 
-// Register cria um novo usuário
+// LogoutAll encerra todas as sessões do usuário autenticado, em todos os dispositivos
+func LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	_, err := db.DB.Exec(context.Background(),
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao encerrar sessões",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Sessões encerradas em todos os dispositivos",
+	})
+}
+
+// Register cria um novo usuário pelo cadastro aberto (OPEN_REGISTRATION), sempre com o papel "volunteer".
+// Papéis de líder e administrador só são concedidos por administradores; times, por convites.
 func Register(c *gin.Context) {
+	if !AppConfig.OpenRegistration {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Cadastro aberto desativado; solicite um convite a um líder",
+		})
+		return
+	}
+
 	var userRequest models.UserRequest
 
 	// Validar o corpo da requisição
@@ -116,17 +360,12 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Definir role padrão se não fornecido
-	if userRequest.Role == "" {
-		userRequest.Role = "volunteer"
-	}
-
 	// Inserir novo usuário
 	var user models.User
 	err = db.DB.QueryRow(context.Background(),
-		"INSERT INTO users (username, password, name, email, role) VALUES ($1, $2, $3, $4, $5) RETURNING id, username, name, email, role, created_at",
-		userRequest.Username, string(hashedPassword), userRequest.Name, userRequest.Email, userRequest.Role).
-		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.CreatedAt)
+		"INSERT INTO users (username, password, name, email, role) VALUES ($1, $2, $3, $4, 'volunteer') RETURNING id, username, name, email, role, active, created_at",
+		userRequest.Username, string(hashedPassword), userRequest.Name, userRequest.Email).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
@@ -143,6 +382,75 @@ func Register(c *gin.Context) {
 	})
 }
 
+// recordLoginFailure registra a falha de login e audita o bloqueio, se esta falha o provocou
+func recordLoginFailure(c *gin.Context, username string, userID *int) {
+	locked, until := LoginLimiter.RecordFailure(username, c.ClientIP())
+	if locked {
+		recordAuditLog(c, auditLoginLockout, userID,
+			"Usuário "+username+" bloqueado até "+until.Format(time.RFC3339)+" após falhas de login")
+	}
+}
+
+// respondLoginThrottled responde 429 informando quando o login poderá ser tentado novamente
+func respondLoginThrottled(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, models.ApiResponse{
+		Success: false,
+		Error:   "Muitas tentativas de login. Tente novamente em " + strconv.Itoa(seconds) + " segundos",
+	})
+}
+
+// issueTokens grava um novo token de atualização na sessão e gera o token de acesso correspondente.
+// Retorna também o ID do token de atualização criado.
+func issueTokens(tx pgx.Tx, user models.User, sessionID, userAgent string) (models.LoginResponse, int, error) {
+	response := models.LoginResponse{User: user}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return response, 0, err
+	}
+
+	var tokenID int
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO refresh_tokens (user_id, session_id, token_hash, user_agent, expires_at)
+		 VALUES ($1, $2, $3, $4, NOW() + make_interval(days => $5))
+		 RETURNING id`,
+		user.ID, sessionID, utils.HashToken(refreshToken), userAgent, AppConfig.RefreshTokenTTLDays).
+		Scan(&tokenID)
+	if err != nil {
+		return response, 0, err
+	}
+
+	response.Token, response.ExpiresAt, err = utils.GenerateToken(user, sessionID)
+	if err != nil {
+		return response, 0, err
+	}
+	response.RefreshToken = refreshToken
+
+	return response, tokenID, nil
+}
+
+// revokeSession revoga os tokens de atualização ainda válidos de uma sessão
+func revokeSession(tx pgx.Tx, sessionID string) error {
+	_, err := tx.Exec(context.Background(),
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE session_id = $1 AND revoked_at IS NULL", sessionID)
+	return err
+}
+
+// revokeUserSessions revoga todas as sessões do usuário (troca de papel, desativação)
+func revokeUserSessions(tx pgx.Tx, userID int) error {
+	_, err := tx.Exec(context.Background(),
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return err
+}
+
+// GetJWKS publica as chaves públicas que verificam os tokens emitidos por este servidor
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
+
 // GetProfile retorna os dados do usuário autenticado
 func GetProfile(c *gin.Context) {
 	// Obter ID do usuário do contexto (definido pelo middleware de autenticação)
@@ -158,8 +466,8 @@ func GetProfile(c *gin.Context) {
 	// Obter dados do usuário do banco de dados
 	var user models.User
 	err := db.DB.QueryRow(context.Background(),
-		"SELECT id, username, name, email, role, created_at FROM users WHERE id = $1",
-		userID).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.CreatedAt)
+		"SELECT id, username, name, email, role, active, COALESCE(phone, ''), created_at FROM users WHERE id = $1",
+		userID).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.Phone, &user.CreatedAt)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
@@ -173,4 +481,95 @@ func GetProfile(c *gin.Context) {
 		Success: true,
 		Data:    user,
 	})
-}
\ No newline at end of file
+}
+
+// UpdateProfilePhone cadastra o telefone do usuário logado, usado nas notificações por SMS e WhatsApp.
+// Um valor vazio remove o telefone e desativa esses canais.
+func UpdateProfilePhone(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	var req models.PhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	var phone *string
+	if strings.TrimSpace(req.Phone) != "" {
+		normalized, ok := utils.NormalizePhone(req.Phone)
+		if !ok {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Telefone inválido: use o formato internacional com o código do país (ex.: +5511999998888)",
+			})
+			return
+		}
+		phone = &normalized
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var user models.User
+	err = tx.QueryRow(context.Background(),
+		`UPDATE users SET phone = $1 WHERE id = $2
+		 RETURNING id, username, name, email, role, active, COALESCE(phone, ''), created_at`,
+		phone, userID).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active,
+		&user.Phone, &user.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar telefone",
+		})
+		return
+	}
+
+	// Sem telefone, as mensagens por SMS/WhatsApp não teriam destino
+	if phone == nil {
+		_, err = tx.Exec(context.Background(),
+			`UPDATE notification_preferences SET enabled = false, updated_at = NOW()
+			 WHERE user_id = $1 AND channel = ANY($2) AND enabled = true`,
+			userID, []string{utils.TextChannelSMS, utils.TextChannelWhatsApp})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao atualizar preferências de notificação",
+			})
+			return
+		}
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Telefone atualizado com sucesso",
+		Data:    user,
+	})
+}
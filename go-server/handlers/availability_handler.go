@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// GetAvailabilityRules retorna todas as regras de disponibilidade (opcionalmente filtradas por voluntário)
+func GetAvailabilityRules(c *gin.Context) {
+	query := `SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules`
+	var args []interface{}
+
+	// Manter compatibilidade com o Node.js, que aceita ?volunteerId=
+	if volunteerIDParam := c.Query("volunteerId"); volunteerIDParam != "" {
+		volunteerID, err := strconv.Atoi(volunteerIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "ID de voluntário inválido",
+			})
+			return
+		}
+		query += " WHERE volunteer_id = $1"
+		args = append(args, volunteerID)
+	}
+	query += " ORDER BY volunteer_id, id"
+
+	rules, err := queryAvailabilityRules(db.DB, query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar regras de disponibilidade",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    rules,
+	})
+}
+
+// GetAvailabilityRule retorna uma regra de disponibilidade específica pelo ID
+func GetAvailabilityRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var rule models.AvailabilityRule
+	err = db.DB.QueryRow(context.Background(),
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules WHERE id = $1`, id).
+		Scan(&rule.ID, &rule.VolunteerID, &rule.Description, &rule.DayOfWeek,
+			&rule.StartTime, &rule.EndTime, &rule.StartDate, &rule.EndDate)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Regra de disponibilidade não encontrada",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    rule,
+	})
+}
+
+// GetAvailabilityRulesByVolunteer retorna todas as regras de disponibilidade de um voluntário
+func GetAvailabilityRulesByVolunteer(c *gin.Context) {
+	volunteerID, err := strconv.Atoi(c.Param("volunteerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID de voluntário inválido",
+		})
+		return
+	}
+
+	// Verificar se o voluntário existe
+	var volunteerExists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM volunteers WHERE id = $1)", volunteerID).Scan(&volunteerExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar voluntário",
+		})
+		return
+	}
+
+	if !volunteerExists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Voluntário não encontrado",
+		})
+		return
+	}
+
+	rules, err := queryAvailabilityRules(db.DB,
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules
+		 WHERE volunteer_id = $1
+		 ORDER BY id`, volunteerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar regras de disponibilidade do voluntário",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    rules,
+	})
+}
+
+// CreateAvailabilityRule cria uma nova regra de disponibilidade
+func CreateAvailabilityRule(c *gin.Context) {
+	var ruleRequest models.AvailabilityRuleRequest
+
+	if err := c.ShouldBindJSON(&ruleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if msg := validateAvailabilityRuleRequest(ruleRequest); msg != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	// Apenas o próprio voluntário ou os líderes do time dele gerenciam suas regras
+	if !requireVolunteerSelfOrTeamScope(c, ruleRequest.VolunteerID) {
+		return
+	}
+
+	// Criar regra de disponibilidade
+	var rule models.AvailabilityRule
+	err := db.DB.QueryRow(context.Background(),
+		`INSERT INTO availability_rules (volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date`,
+		ruleRequest.VolunteerID, ruleRequest.Description, ruleRequest.DayOfWeek,
+		ruleRequest.StartTime, ruleRequest.EndTime, ruleRequest.StartDate, ruleRequest.EndDate).
+		Scan(&rule.ID, &rule.VolunteerID, &rule.Description, &rule.DayOfWeek,
+			&rule.StartTime, &rule.EndTime, &rule.StartDate, &rule.EndDate)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar regra de disponibilidade",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Regra de disponibilidade criada com sucesso",
+		Data:    rule,
+	})
+}
+
+// UpdateAvailabilityRule atualiza uma regra de disponibilidade existente
+func UpdateAvailabilityRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var ruleRequest models.AvailabilityRuleRequest
+	if err := c.ShouldBindJSON(&ruleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if msg := validateAvailabilityRuleRequest(ruleRequest); msg != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	// Verificar se a regra existe
+	currentVolunteerID, found := lookupAvailabilityRuleVolunteer(c, id)
+	if !found {
+		return
+	}
+
+	// Apenas o próprio voluntário ou os líderes do time dele gerenciam suas regras (a atual e a nova)
+	if !requireVolunteerSelfOrTeamScope(c, currentVolunteerID) {
+		return
+	}
+	if ruleRequest.VolunteerID != currentVolunteerID && !requireVolunteerSelfOrTeamScope(c, ruleRequest.VolunteerID) {
+		return
+	}
+
+	// Atualizar regra de disponibilidade
+	var rule models.AvailabilityRule
+	err = db.DB.QueryRow(context.Background(),
+		`UPDATE availability_rules
+		 SET volunteer_id = $1, description = $2, day_of_week = $3, start_time = $4, end_time = $5,
+		     start_date = $6, end_date = $7
+		 WHERE id = $8
+		 RETURNING id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date`,
+		ruleRequest.VolunteerID, ruleRequest.Description, ruleRequest.DayOfWeek,
+		ruleRequest.StartTime, ruleRequest.EndTime, ruleRequest.StartDate, ruleRequest.EndDate, id).
+		Scan(&rule.ID, &rule.VolunteerID, &rule.Description, &rule.DayOfWeek,
+			&rule.StartTime, &rule.EndTime, &rule.StartDate, &rule.EndDate)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar regra de disponibilidade",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Regra de disponibilidade atualizada com sucesso",
+		Data:    rule,
+	})
+}
+
+// DeleteAvailabilityRule remove uma regra de disponibilidade
+func DeleteAvailabilityRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	// Verificar se a regra existe
+	volunteerID, found := lookupAvailabilityRuleVolunteer(c, id)
+	if !found {
+		return
+	}
+
+	// Apenas o próprio voluntário ou os líderes do time dele gerenciam suas regras
+	if !requireVolunteerSelfOrTeamScope(c, volunteerID) {
+		return
+	}
+
+	// Excluir regra de disponibilidade
+	_, err = db.DB.Exec(context.Background(), "DELETE FROM availability_rules WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir regra de disponibilidade",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Regra de disponibilidade excluída com sucesso",
+	})
+}
+
+// lookupAvailabilityRuleVolunteer busca o voluntário dono da regra, respondendo 404 se ela não existir
+func lookupAvailabilityRuleVolunteer(c *gin.Context, id int) (int, bool) {
+	var volunteerID int
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT volunteer_id FROM availability_rules WHERE id = $1", id).Scan(&volunteerID)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Regra de disponibilidade não encontrada",
+		})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar regra de disponibilidade",
+		})
+		return 0, false
+	}
+	return volunteerID, true
+}
+
+// queryAvailabilityRules executa uma consulta que retorna as colunas de availability_rules
+func queryAvailabilityRules(q rowQuerier, query string, args ...interface{}) ([]models.AvailabilityRule, error) {
+	rows, err := q.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AvailabilityRule
+	for rows.Next() {
+		var rule models.AvailabilityRule
+		if err := rows.Scan(&rule.ID, &rule.VolunteerID, &rule.Description, &rule.DayOfWeek,
+			&rule.StartTime, &rule.EndTime, &rule.StartDate, &rule.EndDate); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// validateAvailabilityRuleRequest valida os campos de uma regra de disponibilidade.
+// Retorna uma mensagem de erro vazia quando a regra é válida.
+func validateAvailabilityRuleRequest(req models.AvailabilityRuleRequest) string {
+	if req.DayOfWeek != nil && (*req.DayOfWeek < 0 || *req.DayOfWeek > 6) {
+		return "Dia da semana inválido: use 0 (domingo) a 6 (sábado)"
+	}
+
+	// Horários são opcionais (regra de dia inteiro), mas devem vir em par
+	if (req.StartTime == nil) != (req.EndTime == nil) {
+		return "Informe horário inicial e final, ou nenhum dos dois"
+	}
+
+	if req.StartTime != nil {
+		start, err := parseClock(*req.StartTime)
+		if err != nil {
+			return "Horário inicial inválido: use o formato HH:MM"
+		}
+		end, err := parseClock(*req.EndTime)
+		if err != nil {
+			return "Horário final inválido: use o formato HH:MM"
+		}
+		if !end.After(start) {
+			return "O horário final deve ser posterior ao horário inicial"
+		}
+	}
+
+	if req.StartDate != nil && req.EndDate != nil && req.EndDate.Before(*req.StartDate) {
+		return "A data final deve ser igual ou posterior à data inicial"
+	}
+
+	return ""
+}
+
+// parseClock interpreta um horário no formato HH:MM
+func parseClock(value string) (time.Time, error) {
+	return time.Parse("15:04", value)
+}
+
+// checkAvailabilityViolations retorna as regras de disponibilidade do voluntário que
+// coincidem com a data e o horário do evento
+func checkAvailabilityViolations(q rowQuerier, eventID, volunteerID int) ([]models.AvailabilityViolation, error) {
+	var eventDate time.Time
+	err := q.QueryRow(context.Background(),
+		"SELECT event_date FROM events WHERE id = $1", eventID).Scan(&eventDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := queryAvailabilityRules(q,
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules
+		 WHERE volunteer_id = $1
+		 ORDER BY id`, volunteerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchAvailabilityRules(rules, eventDate), nil
+}
+
+// matchAvailabilityRules filtra as regras que bloqueiam o voluntário no momento informado.
+// Cada campo nulo da regra é tratado como "qualquer valor".
+func matchAvailabilityRules(rules []models.AvailabilityRule, at time.Time) []models.AvailabilityViolation {
+	var violations []models.AvailabilityViolation
+	day := truncateToDay(at)
+	minuteOfDay := at.Hour()*60 + at.Minute()
+
+	for _, rule := range rules {
+		var reasons []string
+
+		if rule.DayOfWeek != nil {
+			if int(at.Weekday()) != *rule.DayOfWeek {
+				continue
+			}
+			reasons = append(reasons, "indisponível às "+weekdayNames[*rule.DayOfWeek])
+		}
+
+		if rule.StartTime != nil && rule.EndTime != nil {
+			start, err := parseClock(*rule.StartTime)
+			if err != nil {
+				continue
+			}
+			end, err := parseClock(*rule.EndTime)
+			if err != nil {
+				continue
+			}
+			if minuteOfDay < start.Hour()*60+start.Minute() || minuteOfDay >= end.Hour()*60+end.Minute() {
+				continue
+			}
+			reasons = append(reasons, "entre "+*rule.StartTime+" e "+*rule.EndTime)
+		}
+
+		if rule.StartDate != nil {
+			if day.Before(truncateToDay(*rule.StartDate)) {
+				continue
+			}
+		}
+		if rule.EndDate != nil {
+			if day.After(truncateToDay(*rule.EndDate)) {
+				continue
+			}
+		}
+		if rule.StartDate != nil || rule.EndDate != nil {
+			reasons = append(reasons, "no período "+formatRuleDate(rule.StartDate)+" a "+formatRuleDate(rule.EndDate))
+		}
+
+		reason := "indisponível"
+		if len(reasons) > 0 {
+			reason = strings.Join(reasons, ", ")
+		}
+
+		violations = append(violations, models.AvailabilityViolation{
+			Rule:   rule,
+			Reason: reason,
+		})
+	}
+
+	return violations
+}
+
+// weekdayNames nomeia os dias da semana na mesma numeração de availability_rules.day_of_week
+var weekdayNames = []string{"domingos", "segundas-feiras", "terças-feiras", "quartas-feiras", "quintas-feiras", "sextas-feiras", "sábados"}
+
+// formatRuleDate formata uma data opcional de regra de disponibilidade
+func formatRuleDate(date *time.Time) string {
+	if date == nil {
+		return "..."
+	}
+	return date.Format("02/01/2006")
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+// calendarFeedLookbackDays define quantos dias de escalas passadas aparecem no feed
+const calendarFeedLookbackDays = 90
+
+// GetCalendarFeed retorna os endereços dos feeds iCalendar do usuário logado, criando o token se necessário
+func GetCalendarFeed(c *gin.Context) {
+	respondCalendarFeed(c, false)
+}
+
+// ResetCalendarFeed gera um novo token, invalidando os endereços de feed anteriores
+func ResetCalendarFeed(c *gin.Context) {
+	respondCalendarFeed(c, true)
+}
+
+// GetVolunteerCalendar retorna o feed .ics com as escalas do dono do token
+func GetVolunteerCalendar(c *gin.Context) {
+	user, ok := calendarTokenUser(c)
+	if !ok {
+		return
+	}
+
+	events, err := loadCalendarEvents(false, "v.user_id = $1", user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar agendamentos",
+		})
+		return
+	}
+
+	writeCalendar(c, "Escala - "+user.Name, events)
+}
+
+// GetTeamCalendar retorna o feed .ics com as escalas de um time (para o líder do time ou administradores)
+func GetTeamCalendar(c *gin.Context) {
+	user, ok := calendarTokenUser(c)
+	if !ok {
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("teamId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID de time inválido",
+		})
+		return
+	}
+
+	var teamName string
+	var leaderID *int
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT name, leader_id FROM teams WHERE id = $1", teamID).Scan(&teamName, &leaderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Time não encontrado",
+		})
+		return
+	}
+
+	if user.Role != "admin" && (leaderID == nil || *leaderID != user.ID) {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Apenas o líder do time pode acessar este calendário",
+		})
+		return
+	}
+
+	events, err := loadCalendarEvents(true, "v.team_id = $1", teamID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar agendamentos do time",
+		})
+		return
+	}
+
+	writeCalendar(c, "Escala - "+teamName, events)
+}
+
+// respondCalendarFeed devolve o token e os endereços dos feeds do usuário logado
+func respondCalendarFeed(c *gin.Context, reset bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	var role string
+	var token *string
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT role, calendar_token FROM users WHERE id = $1", userID).Scan(&role, &token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	if token == nil || reset {
+		newToken, err := utils.GenerateRandomToken(24)
+		if err == nil {
+			_, err = db.DB.Exec(context.Background(),
+				"UPDATE users SET calendar_token = $1 WHERE id = $2", newToken, userID)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao gerar token do calendário",
+			})
+			return
+		}
+		token = &newToken
+	}
+
+	// Administradores podem assinar qualquer time; líderes, os times que lideram
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT id, name FROM teams WHERE $1 = 'admin' OR leader_id = $2 ORDER BY name", role, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar times",
+		})
+		return
+	}
+	defer rows.Close()
+
+	baseURL := requestBaseURL(c) + "/api/calendar/" + *token
+	feed := models.CalendarFeed{
+		Token: *token,
+		URL:   baseURL + "/schedules.ics",
+	}
+	for rows.Next() {
+		var teamFeed models.TeamCalendarFeed
+		if err := rows.Scan(&teamFeed.TeamID, &teamFeed.TeamName); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar times",
+			})
+			return
+		}
+		teamFeed.URL = baseURL + "/teams/" + strconv.Itoa(teamFeed.TeamID) + "/schedules.ics"
+		feed.TeamFeeds = append(feed.TeamFeeds, teamFeed)
+	}
+
+	message := ""
+	if reset {
+		message = "Endereço do calendário redefinido com sucesso"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    feed,
+	})
+}
+
+// calendarTokenUser identifica o usuário ativo dono do token do feed
+func calendarTokenUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT id, username, name, email, role, active, created_at FROM users WHERE calendar_token = $1 AND active = true",
+		c.Param("token")).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Calendário não encontrado",
+		})
+		return user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar calendário",
+		})
+		return user, false
+	}
+
+	return user, true
+}
+
+// loadCalendarEvents busca as escalas (mesmo join de GetSchedulesByVolunteer, com papel e voluntário)
+// e as converte em VEVENTs. Em feeds de time, o nome do voluntário entra no título.
+func loadCalendarEvents(teamFeed bool, filter string, args ...interface{}) ([]utils.CalendarEvent, error) {
+	args = append(args, time.Now().AddDate(0, 0, -calendarFeedLookbackDays))
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT s.id, s.status, s.created_at, e.title, e.event_date,
+		        e.event_date + make_interval(mins => e.duration_minutes), e.location, r.name, u.name
+		 FROM schedules s
+		 JOIN events e ON s.event_id = e.id
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN roles r ON v.role_id = r.id
+		 JOIN users u ON v.user_id = u.id
+		 WHERE `+filter+` AND e.event_date >= $`+strconv.Itoa(len(args))+`
+		 ORDER BY e.event_date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []utils.CalendarEvent
+	for rows.Next() {
+		var scheduleID int
+		var status, eventTitle, location, roleName, volunteerName string
+		var createdAt, eventDate, endDate time.Time
+		if err := rows.Scan(&scheduleID, &status, &createdAt, &eventTitle, &eventDate, &endDate, &location,
+			&roleName, &volunteerName); err != nil {
+			return nil, err
+		}
+
+		summary := eventTitle + " - " + roleName
+		if teamFeed {
+			summary = eventTitle + " - " + volunteerName + " (" + roleName + ")"
+		}
+
+		events = append(events, utils.CalendarEvent{
+			UID:          "schedule-" + strconv.Itoa(scheduleID) + "@escala-minc",
+			Summary:      summary,
+			Description:  "Função: " + roleName + "\nStatus: " + scheduleStatusLabel(status),
+			Location:     location,
+			Start:        eventDate,
+			End:          endDate,
+			Status:       calendarStatus(status),
+			LastModified: createdAt,
+		})
+	}
+
+	return events, rows.Err()
+}
+
+// writeCalendar responde com o documento iCalendar
+func writeCalendar(c *gin.Context, name string, events []utils.CalendarEvent) {
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(utils.BuildCalendar(name, events)))
+}
+
+// calendarStatus converte o status do agendamento para o STATUS do VEVENT
+func calendarStatus(status string) string {
+	switch status {
+	case "cancelled":
+		return "CANCELLED"
+	case "pending":
+		return "TENTATIVE"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// scheduleStatusLabel traduz o status do agendamento para exibição
+func scheduleStatusLabel(status string) string {
+	switch status {
+	case "cancelled":
+		return "cancelado"
+	case "pending":
+		return "pendente"
+	default:
+		return "confirmado"
+	}
+}
+
+// requestBaseURL monta a URL base (esquema e host) da requisição, respeitando proxies
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,6 @@
+package handlers
+
+import "volunteer-scheduler/config"
+
+// AppConfig é a configuração do servidor, carregada uma única vez em main.go (depois do .env)
+var AppConfig config.Config
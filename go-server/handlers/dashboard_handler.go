@@ -3,6 +3,8 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -90,9 +92,32 @@ func GetDashboardStats(c *gin.Context) {
 		return
 	}
 
-	// Obter número de conflitos de agendamento
-	// Em uma implementação real, isso seria mais complexo para detectar conflitos reais
-	stats.SchedulingConflicts = 0
+	// Obter número de conflitos de agendamento em eventos futuros
+	now := time.Now()
+	conflicts, err := findSchedulingConflicts(conflictFilter{From: &now})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao detectar conflitos de agendamento",
+		})
+		return
+	}
+	stats.SchedulingConflicts = len(conflicts)
+
+	// Obter número de eventos futuros com vagas em aberto na escala
+	coverages, err := loadEventCoverage("e.event_date >= $1", time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao calcular cobertura das escalas",
+		})
+		return
+	}
+	for _, coverage := range coverages {
+		if coverage.OpenSlots > 0 {
+			stats.UnderstaffedEvents++
+		}
+	}
 
 	// Obter distribuição de voluntários por equipe
 	rows, err := db.DB.Query(context.Background(), 
@@ -194,42 +219,62 @@ func GetDashboardStats(c *gin.Context) {
 	})
 }
 
-// GetConflicts retorna todos os conflitos de agendamento
+// GetConflicts retorna os conflitos de agendamento, com filtros opcionais por time (teamId),
+// voluntário (volunteerId) e período (startDate/endDate no formato AAAA-MM-DD)
 func GetConflicts(c *gin.Context) {
-	// Buscar eventos com voluntários agendados no mesmo dia
-	rows, err := db.DB.Query(context.Background(), 
-		`WITH volunteer_days AS (
-			SELECT 
-				v.id as volunteer_id,
-				u.name as volunteer_name,
-				DATE(e.event_date) as event_day,
-				COUNT(*) as event_count
-			FROM schedules s
-			JOIN volunteers v ON s.volunteer_id = v.id
-			JOIN users u ON v.user_id = u.id
-			JOIN events e ON s.event_id = e.id
-			GROUP BY v.id, u.name, DATE(e.event_date)
-			HAVING COUNT(*) > 1
-		)
-		SELECT 
-			vd.volunteer_id,
-			vd.volunteer_name,
-			vd.event_day,
-			vd.event_count,
-			json_agg(
-				json_build_object(
-					'id', e.id,
-					'title', e.title,
-					'location', e.location,
-					'eventDate', e.event_date,
-					'scheduleId', s.id
-				)
-			) as conflicting_events
-		FROM volunteer_days vd
-		JOIN schedules s ON s.volunteer_id = vd.volunteer_id
-		JOIN events e ON s.event_id = e.id AND DATE(e.event_date) = vd.event_day
-		GROUP BY vd.volunteer_id, vd.volunteer_name, vd.event_day, vd.event_count
-		ORDER BY vd.event_day, vd.volunteer_name`)
+	var filter conflictFilter
+
+	if value := c.Query("teamId"); value != "" {
+		teamID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "ID de time inválido",
+			})
+			return
+		}
+		filter.TeamID = &teamID
+	}
+
+	if value := c.Query("volunteerId"); value != "" {
+		volunteerID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "ID de voluntário inválido",
+			})
+			return
+		}
+		filter.VolunteerID = &volunteerID
+	}
+
+	if value := c.Query("startDate"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Data inicial inválida: use o formato AAAA-MM-DD",
+			})
+			return
+		}
+		filter.From = &parsed
+	}
+
+	if value := c.Query("endDate"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Data final inválida: use o formato AAAA-MM-DD",
+			})
+			return
+		}
+		// A data final é inclusiva
+		parsed = parsed.AddDate(0, 0, 1)
+		filter.To = &parsed
+	}
+
+	conflicts, err := findSchedulingConflicts(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -237,45 +282,150 @@ func GetConflicts(c *gin.Context) {
 		})
 		return
 	}
-	defer rows.Close()
 
-	type ConflictEvent struct {
-		ID         int       `json:"id"`
-		Title      string    `json:"title"`
-		Location   string    `json:"location"`
-		EventDate  time.Time `json:"eventDate"`
-		ScheduleID int       `json:"scheduleId"`
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    conflicts,
+	})
+}
+
+// conflictFilter restringe a busca de conflitos; campos nil não filtram
+type conflictFilter struct {
+	TeamID      *int
+	VolunteerID *int
+	From        *time.Time
+	To          *time.Time
+}
+
+// findSchedulingConflicts detecta conflitos com a mesma regra de checkSchedulingConflict: agendamentos
+// não cancelados de um mesmo usuário, em eventos diferentes, cujos horários (mais o intervalo de
+// deslocamento) se sobrepõem. Agendamentos sobrepostos em cadeia formam um único conflito.
+func findSchedulingConflicts(filter conflictFilter) ([]models.Conflict, error) {
+	args := []interface{}{int(schedulingBuffer() / time.Minute)}
+	conditions := ""
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions += " AND e1.event_date >= $" + strconv.Itoa(len(args)) + " AND e2.event_date >= $" + strconv.Itoa(len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions += " AND e1.event_date < $" + strconv.Itoa(len(args)) + " AND e2.event_date < $" + strconv.Itoa(len(args))
+	}
+	if filter.TeamID != nil {
+		args = append(args, *filter.TeamID)
+		conditions += " AND (v1.team_id = $" + strconv.Itoa(len(args)) + " OR v2.team_id = $" + strconv.Itoa(len(args)) + ")"
+	}
+	if filter.VolunteerID != nil {
+		args = append(args, *filter.VolunteerID)
+		conditions += " AND v1.user_id = (SELECT user_id FROM volunteers WHERE id = $" + strconv.Itoa(len(args)) + ")"
 	}
 
-	type Conflict struct {
-		VolunteerID   int             `json:"volunteerId"`
-		VolunteerName string          `json:"volunteerName"`
-		EventDay      string          `json:"eventDay"`
-		EventCount    int             `json:"eventCount"`
-		Events        []ConflictEvent `json:"events"`
+	// Pares de agendamentos sobrepostos
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT s1.id, s2.id
+		 FROM schedules s1
+		 JOIN volunteers v1 ON s1.volunteer_id = v1.id
+		 JOIN events e1 ON s1.event_id = e1.id
+		 JOIN schedules s2 ON s2.id > s1.id AND s2.event_id != s1.event_id AND s2.status != 'cancelled'
+		 JOIN volunteers v2 ON s2.volunteer_id = v2.id AND v2.user_id = v1.user_id
+		 JOIN events e2 ON s2.event_id = e2.id
+		 WHERE s1.status != 'cancelled'
+		 AND e1.event_date < e2.event_date + make_interval(mins => e2.duration_minutes + $1)
+		 AND e2.event_date < e1.event_date + make_interval(mins => e1.duration_minutes + $1)`+conditions, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	var conflicts []Conflict
+	// Agrupar os pares em conflitos (componentes conexos)
+	parent := map[int]int{}
+	var find func(id int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	var scheduleIDs []int
 	for rows.Next() {
-		var conflict Conflict
-		var eventsJSON string
-		if err := rows.Scan(&conflict.VolunteerID, &conflict.VolunteerName, 
-			&conflict.EventDay, &conflict.EventCount, &eventsJSON); err != nil {
-			c.JSON(http.StatusInternalServerError, models.ApiResponse{
-				Success: false,
-				Error:   "Erro ao processar conflitos",
-			})
-			return
+		var first, second int
+		if err := rows.Scan(&first, &second); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		for _, id := range []int{first, second} {
+			if _, ok := parent[id]; !ok {
+				parent[id] = id
+				scheduleIDs = append(scheduleIDs, id)
+			}
 		}
-		
-		// Em uma implementação real, você parsearia o JSON em Go
-		// Aqui estamos simplificando e enviando diretamente ao cliente
-		conflict.Events = []ConflictEvent{} // Placeholder
-		conflicts = append(conflicts, conflict)
+		parent[find(first)] = find(second)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(scheduleIDs) == 0 {
+		return []models.Conflict{}, nil
 	}
 
-	c.JSON(http.StatusOK, models.ApiResponse{
-		Success: true,
-		Data:    conflicts,
+	// Detalhes dos agendamentos envolvidos
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT s.id, s.volunteer_id, v.user_id, u.name, v.team_id, t.name, r.name,
+		        e.id, e.title, e.location, e.event_date, e.event_date + make_interval(mins => e.duration_minutes)
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN users u ON v.user_id = u.id
+		 JOIN teams t ON v.team_id = t.id
+		 JOIN roles r ON v.role_id = r.id
+		 JOIN events e ON s.event_id = e.id
+		 WHERE s.id = ANY($1)
+		 ORDER BY e.event_date, s.id`, scheduleIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[int]*models.Conflict{}
+	var conflicts []*models.Conflict
+	for rows.Next() {
+		var event models.ConflictEvent
+		var userID int
+		var volunteerName string
+		if err := rows.Scan(&event.ScheduleID, &event.VolunteerID, &userID, &volunteerName, &event.TeamID,
+			&event.TeamName, &event.RoleName, &event.ID, &event.Title, &event.Location, &event.EventDate,
+			&event.EndDate); err != nil {
+			return nil, err
+		}
+
+		root := find(event.ScheduleID)
+		conflict, ok := groups[root]
+		if !ok {
+			conflict = &models.Conflict{
+				UserID:        userID,
+				VolunteerID:   event.VolunteerID,
+				VolunteerName: volunteerName,
+				EventDay:      event.EventDate.Format("2006-01-02"),
+			}
+			groups[root] = conflict
+			conflicts = append(conflicts, conflict)
+		}
+		conflict.Events = append(conflict.Events, event)
+		conflict.EventCount++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Conflict, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		result = append(result, *conflict)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].EventDay != result[j].EventDay {
+			return result[i].EventDay < result[j].EventDay
+		}
+		return result[i].VolunteerName < result[j].VolunteerName
 	})
-}
\ No newline at end of file
+
+	return result, nil
+}
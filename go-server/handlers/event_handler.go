@@ -7,14 +7,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
 	"volunteer-scheduler/db"
 	"volunteer-scheduler/models"
 )
 
+// defaultEventDurationMinutes é a duração de um evento quando nem duração nem término são informados
+const defaultEventDurationMinutes = 120
+
 // GetEvents retorna todos os eventos
 func GetEvents(c *gin.Context) {
 	rows, err := db.DB.Query(context.Background(), 
-		`SELECT id, title, description, location, event_date, event_type, recurrent, created_at 
+		`SELECT id, title, description, location, event_date, duration_minutes,
+		        event_date + make_interval(mins => duration_minutes), event_type, recurrent, series_id, occurrence_date, series_exception, created_at 
 		 FROM events
 		 ORDER BY event_date DESC`)
 	if err != nil {
@@ -30,7 +35,8 @@ func GetEvents(c *gin.Context) {
 	for rows.Next() {
 		var event models.Event
 		if err := rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, 
-			&event.EventDate, &event.EventType, &event.Recurrent, &event.CreatedAt); err != nil {
+			&event.EventDate, &event.DurationMinutes, &event.EndDate, &event.EventType, &event.Recurrent,
+			&event.SeriesID, &event.OccurrenceDate, &event.SeriesException, &event.CreatedAt); err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
 				Error:   "Erro ao processar eventos",
@@ -59,10 +65,12 @@ func GetEvent(c *gin.Context) {
 
 	var event models.Event
 	err = db.DB.QueryRow(context.Background(),
-		`SELECT id, title, description, location, event_date, event_type, recurrent, created_at 
+		`SELECT id, title, description, location, event_date, duration_minutes,
+		        event_date + make_interval(mins => duration_minutes), event_type, recurrent, series_id, occurrence_date, series_exception, created_at 
 		 FROM events WHERE id = $1`, id).
 		Scan(&event.ID, &event.Title, &event.Description, &event.Location, 
-			&event.EventDate, &event.EventType, &event.Recurrent, &event.CreatedAt)
+			&event.EventDate, &event.DurationMinutes, &event.EndDate, &event.EventType, &event.Recurrent,
+			&event.SeriesID, &event.OccurrenceDate, &event.SeriesException, &event.CreatedAt)
 
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ApiResponse{
@@ -90,15 +98,29 @@ func CreateEvent(c *gin.Context) {
 		return
 	}
 
+	durationMinutes, message := eventDurationMinutes(eventRequest)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+	if durationMinutes == 0 {
+		durationMinutes = defaultEventDurationMinutes
+	}
+
 	var event models.Event
 	err := db.DB.QueryRow(context.Background(),
-		`INSERT INTO events (title, description, location, event_date, event_type, recurrent) 
-		 VALUES ($1, $2, $3, $4, $5, $6) 
-		 RETURNING id, title, description, location, event_date, event_type, recurrent, created_at`,
+		`INSERT INTO events (title, description, location, event_date, event_type, recurrent, duration_minutes) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) 
+		 RETURNING id, title, description, location, event_date, duration_minutes,
+		        event_date + make_interval(mins => duration_minutes), event_type, recurrent, series_id, occurrence_date, series_exception, created_at`,
 		eventRequest.Title, eventRequest.Description, eventRequest.Location, 
-		eventRequest.EventDate, eventRequest.EventType, eventRequest.Recurrent).
+		eventRequest.EventDate, eventRequest.EventType, eventRequest.Recurrent, durationMinutes).
 		Scan(&event.ID, &event.Title, &event.Description, &event.Location, 
-			&event.EventDate, &event.EventType, &event.Recurrent, &event.CreatedAt)
+			&event.EventDate, &event.DurationMinutes, &event.EndDate, &event.EventType, &event.Recurrent,
+			&event.SeriesID, &event.OccurrenceDate, &event.SeriesException, &event.CreatedAt)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
@@ -135,9 +157,38 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	// Sem duração nem término informados, a duração atual é mantida
+	durationMinutes, message := eventDurationMinutes(eventRequest)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	// Escopo da edição para eventos de uma série: this (só esta ocorrência), following (esta e as seguintes) ou all
+	scope := c.DefaultQuery("scope", "this")
+	if !isValidSeriesScope(scope) {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Escopo inválido (use this, following ou all)",
+		})
+		return
+	}
+
 	// Verificar se o evento existe
-	var exists bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)", id).Scan(&exists)
+	var current models.Event
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT event_date, series_id, occurrence_date FROM events WHERE id = $1", id).
+		Scan(&current.EventDate, &current.SeriesID, &current.OccurrenceDate)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Evento não encontrado",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -146,25 +197,50 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
+	if scope != "this" && current.SeriesID == nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
 			Success: false,
-			Error:   "Evento não encontrado",
+			Error:   "O evento não pertence a uma série recorrente",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
 		})
 		return
 	}
+	defer tx.Rollback(context.Background())
 
-	// Atualizar evento
+	if scope != "this" {
+		if err := updateSeriesFromOccurrence(tx, id, current, eventRequest, scope); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao atualizar eventos da série",
+			})
+			return
+		}
+	}
+
+	// Atualizar evento (editar só esta ocorrência a marca como exceção da série)
 	var event models.Event
-	err = db.DB.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`UPDATE events 
-		 SET title = $1, description = $2, location = $3, event_date = $4, event_type = $5, recurrent = $6 
+		 SET title = $1, description = $2, location = $3, event_date = $4, event_type = $5, recurrent = $6,
+		     duration_minutes = COALESCE(NULLIF($9, 0), duration_minutes),
+		     series_exception = (series_id IS NOT NULL AND $8 = 'this')
 		 WHERE id = $7 
-		 RETURNING id, title, description, location, event_date, event_type, recurrent, created_at`,
+		 RETURNING id, title, description, location, event_date, duration_minutes,
+		        event_date + make_interval(mins => duration_minutes), event_type, recurrent, series_id, occurrence_date, series_exception, created_at`,
 		eventRequest.Title, eventRequest.Description, eventRequest.Location, 
-		eventRequest.EventDate, eventRequest.EventType, eventRequest.Recurrent, id).
+		eventRequest.EventDate, eventRequest.EventType, eventRequest.Recurrent, id, scope, durationMinutes).
 		Scan(&event.ID, &event.Title, &event.Description, &event.Location, 
-			&event.EventDate, &event.EventType, &event.Recurrent, &event.CreatedAt)
+			&event.EventDate, &event.DurationMinutes, &event.EndDate, &event.EventType, &event.Recurrent,
+			&event.SeriesID, &event.OccurrenceDate, &event.SeriesException, &event.CreatedAt)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
@@ -174,6 +250,16 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
 		Message: "Evento atualizado com sucesso",
@@ -192,9 +278,28 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
+	// Escopo da exclusão para eventos de uma série: this (só esta ocorrência), following (esta e as seguintes) ou all
+	scope := c.DefaultQuery("scope", "this")
+	if !isValidSeriesScope(scope) {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Escopo inválido (use this, following ou all)",
+		})
+		return
+	}
+
 	// Verificar se o evento existe
-	var exists bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)", id).Scan(&exists)
+	var seriesID *int
+	var occurrenceDate *time.Time
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT series_id, occurrence_date FROM events WHERE id = $1", id).Scan(&seriesID, &occurrenceDate)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Evento não encontrado",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -203,17 +308,45 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
+	if scope != "this" {
+		if seriesID == nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "O evento não pertence a uma série recorrente",
+			})
+			return
+		}
+
+		if scope == "all" {
+			occurrenceDate = nil
+		}
+		deleteSeriesOccurrences(c, *seriesID, occurrenceDate)
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
-			Error:   "Evento não encontrado",
+			Error:   "Erro ao iniciar transação",
 		})
 		return
 	}
+	defer tx.Rollback(context.Background())
 
-	// Verificar dependências (schedules)
+	// Verificar dependências (schedules); o bloqueio do evento impede novos agendamentos até a exclusão
 	var hasSchedules bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM schedules WHERE event_id = $1)", id).Scan(&hasSchedules)
+	err = tx.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM schedules WHERE event_id = e.id) FROM events e WHERE e.id = $1 FOR UPDATE",
+		id).Scan(&hasSchedules)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Evento não encontrado",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -230,8 +363,32 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
+	// Registrar a exclusão na série para que a ocorrência não seja gerada novamente
+	if seriesID != nil {
+		_, err = tx.Exec(context.Background(),
+			"UPDATE event_series SET exdates = array_append(COALESCE(exdates, '{}'), $1::timestamp) WHERE id = $2",
+			occurrenceDate, *seriesID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao atualizar série de eventos",
+			})
+			return
+		}
+	}
+
+	// Excluir necessidades de escala associadas
+	_, err = tx.Exec(context.Background(), "DELETE FROM staffing_requirements WHERE event_id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir necessidades de escala",
+		})
+		return
+	}
+
 	// Excluir evento
-	_, err = db.DB.Exec(context.Background(), "DELETE FROM events WHERE id = $1", id)
+	_, err = tx.Exec(context.Background(), "DELETE FROM events WHERE id = $1", id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -240,6 +397,16 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
 		Message: "Evento excluído com sucesso",
@@ -250,7 +417,9 @@ func DeleteEvent(c *gin.Context) {
 func GetUpcomingEvents(c *gin.Context) {
 	// Obter eventos futuros (a partir de hoje)
 	rows, err := db.DB.Query(context.Background(), 
-		`SELECT e.id, e.title, e.description, e.location, e.event_date, e.event_type, e.recurrent, e.created_at,
+		`SELECT e.id, e.title, e.description, e.location, e.event_date, e.duration_minutes,
+			e.event_date + make_interval(mins => e.duration_minutes), e.event_type, e.recurrent,
+			e.series_id, e.occurrence_date, e.series_exception, e.created_at,
 			(SELECT COUNT(*) FROM schedules s WHERE s.event_id = e.id) as schedule_count
 		 FROM events e
 		 WHERE e.event_date >= $1
@@ -275,7 +444,8 @@ func GetUpcomingEvents(c *gin.Context) {
 	for rows.Next() {
 		var event UpcomingEvent
 		if err := rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, 
-			&event.EventDate, &event.EventType, &event.Recurrent, &event.CreatedAt, &event.ScheduleCount); err != nil {
+			&event.EventDate, &event.DurationMinutes, &event.EndDate, &event.EventType, &event.Recurrent,
+			&event.SeriesID, &event.OccurrenceDate, &event.SeriesException, &event.CreatedAt, &event.ScheduleCount); err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
 				Error:   "Erro ao processar próximos eventos",
@@ -289,4 +459,16 @@ func GetUpcomingEvents(c *gin.Context) {
 		Success: true,
 		Data:    upcomingEvents,
 	})
-}
\ No newline at end of file
+}
+
+// eventDurationMinutes calcula a duração informada na requisição (pelo término ou pela duração).
+// Retorna 0 quando nenhum dos dois foi informado.
+func eventDurationMinutes(eventRequest models.EventRequest) (int, string) {
+	if eventRequest.EndDate != nil {
+		if !eventRequest.EndDate.After(eventRequest.EventDate) {
+			return 0, "O horário de término deve ser posterior ao início do evento"
+		}
+		return int(eventRequest.EndDate.Sub(eventRequest.EventDate) / time.Minute), ""
+	}
+	return eventRequest.DurationMinutes, ""
+}
@@ -0,0 +1,880 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+const defaultSeriesHorizonDays = 90
+
+const eventSeriesColumns = `id, title, COALESCE(description, ''), location, event_type, rrule, start_date, duration_minutes,
+	until, horizon_days, shift_minutes, exdates, created_at`
+
+// GetEventSeries retorna todas as séries de eventos recorrentes
+func GetEventSeries(c *gin.Context) {
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT "+eventSeriesColumns+" FROM event_series ORDER BY start_date")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar séries de eventos",
+		})
+		return
+	}
+	defer rows.Close()
+
+	var seriesList []models.EventSeries
+	for rows.Next() {
+		series, err := scanEventSeries(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar séries de eventos",
+			})
+			return
+		}
+		seriesList = append(seriesList, series)
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    seriesList,
+	})
+}
+
+// GetEventSeriesByID retorna uma série de eventos específica pelo ID
+func GetEventSeriesByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	series, err := scanEventSeries(db.DB.QueryRow(context.Background(),
+		"SELECT "+eventSeriesColumns+" FROM event_series WHERE id = $1", id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Série de eventos não encontrada",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    series,
+	})
+}
+
+// CreateEventSeries cria uma série recorrente e gera seus eventos até o horizonte configurado
+func CreateEventSeries(c *gin.Context) {
+	var seriesRequest models.EventSeriesRequest
+	if err := c.ShouldBindJSON(&seriesRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if message := validateEventSeriesRequest(&seriesRequest); message != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	series, err := scanEventSeries(tx.QueryRow(context.Background(),
+		`INSERT INTO event_series (title, description, location, event_type, rrule, start_date, duration_minutes,
+		                           until, horizon_days)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING `+eventSeriesColumns,
+		seriesRequest.Title, seriesRequest.Description, seriesRequest.Location, seriesRequest.EventType,
+		seriesRequest.RRule, seriesRequest.StartDate, seriesRequest.DurationMinutes, seriesRequest.Until,
+		seriesRequest.HorizonDays))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar série de eventos",
+		})
+		return
+	}
+
+	created, err := materializeEventSeries(tx, series)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar eventos da série",
+		})
+		return
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Série de eventos criada com sucesso (" + strconv.Itoa(created) + " eventos gerados)",
+		Data:    series,
+	})
+}
+
+// UpdateEventSeries atualiza a série inteira: modelo, regra e ocorrências futuras não editadas individualmente.
+// Ocorrências futuras que deixam de existir na nova regra são removidas; as que já têm agendamentos
+// são mantidas como exceções para não perder a escala.
+func UpdateEventSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var seriesRequest models.EventSeriesRequest
+	if err := c.ShouldBindJSON(&seriesRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if message := validateEventSeriesRequest(&seriesRequest); message != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	series, err := scanEventSeries(tx.QueryRow(context.Background(),
+		`UPDATE event_series
+		 SET title = $1, description = $2, location = $3, event_type = $4, rrule = $5, start_date = $6,
+		     duration_minutes = $7, until = $8, horizon_days = $9
+		 WHERE id = $10
+		 RETURNING `+eventSeriesColumns,
+		seriesRequest.Title, seriesRequest.Description, seriesRequest.Location, seriesRequest.EventType,
+		seriesRequest.RRule, seriesRequest.StartDate, seriesRequest.DurationMinutes, seriesRequest.Until,
+		seriesRequest.HorizonDays, id))
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Série de eventos não encontrada",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar série de eventos",
+		})
+		return
+	}
+
+	// Aplicar o novo modelo às ocorrências futuras que seguem a série
+	_, err = tx.Exec(context.Background(),
+		`UPDATE events SET title = $1, description = $2, location = $3, event_type = $4, duration_minutes = $5
+		 WHERE series_id = $6 AND series_exception = false AND event_date >= $7`,
+		series.Title, series.Description, series.Location, series.EventType, series.DurationMinutes,
+		series.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar eventos da série",
+		})
+		return
+	}
+
+	if err := pruneEventSeries(tx, series); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao remover ocorrências fora da nova regra",
+		})
+		return
+	}
+
+	if _, err := materializeEventSeries(tx, series); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar eventos da série",
+		})
+		return
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Série de eventos atualizada com sucesso",
+		Data:    series,
+	})
+}
+
+// DeleteEventSeries remove a série e todas as suas ocorrências.
+// Ocorrências com agendamentos só são removidas com ?cascade=true.
+func DeleteEventSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var exists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM event_series WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar série de eventos",
+		})
+		return
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Série de eventos não encontrada",
+		})
+		return
+	}
+
+	deleteSeriesOccurrences(c, id, nil)
+}
+
+// ExtendEventSeries gera as ocorrências de uma série até o horizonte a partir de hoje
+func ExtendEventSeries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	created, err := extendEventSeries(id)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Série de eventos não encontrada",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar eventos da série",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Série estendida com sucesso",
+		Data:    gin.H{"created": created},
+	})
+}
+
+// ExtendAllEventSeries estende todas as séries ativas até seus horizontes
+func ExtendAllEventSeries(c *gin.Context) {
+	created, err := ExtendActiveEventSeries()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao estender séries de eventos",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Séries estendidas com sucesso",
+		Data:    gin.H{"created": created},
+	})
+}
+
+// ExtendActiveEventSeries estende todas as séries que ainda não terminaram e retorna quantos eventos foram criados
+func ExtendActiveEventSeries() (int, error) {
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT id FROM event_series WHERE until IS NULL OR until >= $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var seriesIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		seriesIDs = append(seriesIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range seriesIDs {
+		created, err := extendEventSeries(id)
+		if err != nil {
+			return total, err
+		}
+		total += created
+	}
+
+	return total, nil
+}
+
+// StartEventSeriesExtender mantém o horizonte das séries em dia, estendendo-as na inicialização e a cada intervalo
+func StartEventSeriesExtender(interval time.Duration) {
+	go func() {
+		for {
+			if created, err := ExtendActiveEventSeries(); err != nil {
+				log.Printf("Erro ao estender séries de eventos: %v", err)
+			} else if created > 0 {
+				log.Printf("Séries de eventos estendidas: %d eventos criados", created)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// extendEventSeries materializa uma série em sua própria transação
+func extendEventSeries(id int) (int, error) {
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+
+	series, err := scanEventSeries(tx.QueryRow(context.Background(),
+		"SELECT "+eventSeriesColumns+" FROM event_series WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := materializeEventSeries(tx, series)
+	if err != nil {
+		return 0, err
+	}
+
+	return created, tx.Commit(context.Background())
+}
+
+// materializeEventSeries cria os eventos que faltam entre hoje e o horizonte da série.
+// Ocorrências já existentes ou excluídas (exdates) não são recriadas.
+func materializeEventSeries(tx pgx.Tx, series models.EventSeries) (int, error) {
+	rule, err := utils.ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return 0, err
+	}
+
+	from := series.StartDate
+	if today := truncateToDay(time.Now()); from.Before(today) {
+		from = today
+	}
+	to := truncateToDay(time.Now()).AddDate(0, 0, series.HorizonDays+1)
+	if series.Until != nil && series.Until.Before(to) {
+		to = series.Until.Add(time.Second)
+	}
+	if !from.Before(to) {
+		return 0, nil
+	}
+
+	skip := make(map[string]bool)
+	for _, exdate := range series.Exdates {
+		skip[occurrenceKey(exdate)] = true
+	}
+
+	rows, err := tx.Query(context.Background(),
+		"SELECT occurrence_date FROM events WHERE series_id = $1 AND occurrence_date >= $2", series.ID, from)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var occurrence time.Time
+		if err := rows.Scan(&occurrence); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		skip[occurrenceKey(occurrence)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	occurrences, err := rule.Between(series.StartDate, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	shift := time.Duration(series.ShiftMinutes) * time.Minute
+	for _, occurrence := range occurrences {
+		if skip[occurrenceKey(occurrence)] {
+			continue
+		}
+
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO events (title, description, location, event_date, duration_minutes, event_type, recurrent,
+			                     series_id, occurrence_date)
+			 VALUES ($1, $2, $3, $4, $5, $6, true, $7, $8)`,
+			series.Title, series.Description, series.Location, occurrence.Add(shift), series.DurationMinutes,
+			series.EventType, series.ID, occurrence)
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	return created, nil
+}
+
+// pruneEventSeries remove as ocorrências futuras que a regra atual da série não gera mais
+func pruneEventSeries(tx pgx.Tx, series models.EventSeries) error {
+	rule, err := utils.ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	type futureOccurrence struct {
+		eventID      int
+		occurrence   time.Time
+		hasSchedules bool
+	}
+
+	now := time.Now()
+	rows, err := tx.Query(context.Background(),
+		`SELECT e.id, e.occurrence_date, EXISTS(SELECT 1 FROM schedules s WHERE s.event_id = e.id)
+		 FROM events e
+		 WHERE e.series_id = $1 AND e.series_exception = false AND e.event_date >= $2
+		 ORDER BY e.occurrence_date`, series.ID, now)
+	if err != nil {
+		return err
+	}
+
+	var occurrences []futureOccurrence
+	for rows.Next() {
+		var occurrence futureOccurrence
+		if err := rows.Scan(&occurrence.eventID, &occurrence.occurrence, &occurrence.hasSchedules); err != nil {
+			rows.Close()
+			return err
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(occurrences) == 0 {
+		return nil
+	}
+
+	last := occurrences[len(occurrences)-1].occurrence
+	expected, err := rule.Between(series.StartDate, occurrences[0].occurrence, last.Add(time.Second))
+	if err != nil {
+		return err
+	}
+
+	valid := make(map[string]bool)
+	for _, occurrence := range expected {
+		if series.Until == nil || !occurrence.After(*series.Until) {
+			valid[occurrenceKey(occurrence)] = true
+		}
+	}
+
+	var removeIDs, detachIDs []int
+	for _, occurrence := range occurrences {
+		if valid[occurrenceKey(occurrence.occurrence)] {
+			continue
+		}
+		if occurrence.hasSchedules {
+			detachIDs = append(detachIDs, occurrence.eventID)
+		} else {
+			removeIDs = append(removeIDs, occurrence.eventID)
+		}
+	}
+
+	if len(detachIDs) > 0 {
+		_, err = tx.Exec(context.Background(),
+			"UPDATE events SET series_exception = true WHERE id = ANY($1)", detachIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(removeIDs) > 0 {
+		return deleteEventsCascade(tx, removeIDs)
+	}
+
+	return nil
+}
+
+// updateSeriesFromOccurrence aplica a edição de uma ocorrência a "esta e as seguintes" ou à série inteira.
+// "following" divide a série: a original termina antes da ocorrência e uma nova série assume daí em diante.
+// Ocorrências passadas e exceções editadas individualmente são preservadas; a diferença de horário da
+// ocorrência editada é aplicada às demais.
+// A própria ocorrência editada é atualizada pelo chamador, na mesma transação.
+func updateSeriesFromOccurrence(tx pgx.Tx, eventID int, current models.Event, eventRequest models.EventRequest, scope string) error {
+	series, err := scanEventSeries(tx.QueryRow(context.Background(),
+		"SELECT "+eventSeriesColumns+" FROM event_series WHERE id = $1 FOR UPDATE", *current.SeriesID))
+	if err != nil {
+		return err
+	}
+
+	occurrence := *current.OccurrenceDate
+	seriesID := series.ID
+	if scope == "following" && occurrence.After(series.StartDate) {
+		rule, err := utils.ParseRecurrenceRule(series.RRule)
+		if err != nil {
+			return err
+		}
+
+		// COUNT continua valendo para o total: a nova série recebe apenas as ocorrências restantes
+		if rule.Count > 0 {
+			previous, err := rule.Between(series.StartDate, series.StartDate, occurrence)
+			if err != nil {
+				return err
+			}
+			rule.Count -= len(previous)
+		}
+
+		var exdates []time.Time
+		for _, exdate := range series.Exdates {
+			if !exdate.Before(occurrence) {
+				exdates = append(exdates, exdate)
+			}
+		}
+
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO event_series (title, description, location, event_type, rrule, start_date, duration_minutes,
+			                           until, horizon_days, shift_minutes, exdates)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 RETURNING id`,
+			series.Title, series.Description, series.Location, series.EventType, rule.String(), occurrence,
+			series.DurationMinutes, series.Until, series.HorizonDays, series.ShiftMinutes, exdates).Scan(&seriesID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(),
+			"UPDATE event_series SET until = $1 WHERE id = $2", occurrence.Add(-time.Second), series.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(),
+			"UPDATE events SET series_id = $1 WHERE series_id = $2 AND occurrence_date >= $3",
+			seriesID, series.ID, occurrence)
+		if err != nil {
+			return err
+		}
+	}
+
+	shiftMinutes := int(wallClock(eventRequest.EventDate).Sub(wallClock(current.EventDate)) / time.Minute)
+	// Sem duração nem término informados, a duração atual é mantida
+	durationMinutes, _ := eventDurationMinutes(eventRequest)
+
+	_, err = tx.Exec(context.Background(),
+		`UPDATE event_series
+		 SET title = $1, description = $2, location = $3, event_type = $4, shift_minutes = shift_minutes + $5,
+		     duration_minutes = COALESCE(NULLIF($7, 0), duration_minutes)
+		 WHERE id = $6`,
+		eventRequest.Title, eventRequest.Description, eventRequest.Location, eventRequest.EventType,
+		shiftMinutes, seriesID, durationMinutes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`UPDATE events
+		 SET title = $1, description = $2, location = $3, event_type = $4,
+		     event_date = event_date + make_interval(mins => $5),
+		     duration_minutes = COALESCE(NULLIF($9, 0), duration_minutes)
+		 WHERE series_id = $6 AND series_exception = false AND event_date >= $7 AND id != $8`,
+		eventRequest.Title, eventRequest.Description, eventRequest.Location, eventRequest.EventType,
+		shiftMinutes, seriesID, time.Now(), eventID, durationMinutes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// deleteSeriesOccurrences exclui as ocorrências de uma série a partir de from (todas, se nil).
+// Ocorrências com agendamentos bloqueiam a exclusão, a menos que ?cascade=true seja informado;
+// nesse caso agendamentos e pedidos de troca relacionados são removidos junto, sem deixar órfãos.
+func deleteSeriesOccurrences(c *gin.Context, seriesID int, from *time.Time) {
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Bloquear a série e as ocorrências afetadas: agendamentos novos nesses eventos esperam a transação,
+	// então a verificação abaixo vale até a exclusão
+	var seriesStart time.Time
+	err = tx.QueryRow(context.Background(),
+		"SELECT start_date FROM event_series WHERE id = $1 FOR UPDATE", seriesID).Scan(&seriesStart)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Série de eventos não encontrada",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar série de eventos",
+		})
+		return
+	}
+
+	var eventIDs []int
+	rows, err := tx.Query(context.Background(),
+		`SELECT id FROM events WHERE series_id = $1 AND ($2::timestamp IS NULL OR occurrence_date >= $2)
+		 ORDER BY id FOR UPDATE`, seriesID, from)
+	if err == nil {
+		for rows.Next() {
+			var id int
+			if err = rows.Scan(&id); err != nil {
+				break
+			}
+			eventIDs = append(eventIDs, id)
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar eventos da série",
+		})
+		return
+	}
+
+	rows, err = tx.Query(context.Background(),
+		`SELECT e.id, e.event_date, COUNT(s.id)
+		 FROM events e
+		 JOIN schedules s ON s.event_id = e.id
+		 WHERE e.id = ANY($1)
+		 GROUP BY e.id, e.event_date
+		 ORDER BY e.event_date`, eventIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar agendamentos",
+		})
+		return
+	}
+
+	var blocked []models.SeriesBlockedEvent
+	for rows.Next() {
+		var event models.SeriesBlockedEvent
+		if err := rows.Scan(&event.EventID, &event.EventDate, &event.ScheduleCount); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar agendamentos",
+			})
+			return
+		}
+		blocked = append(blocked, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao processar agendamentos",
+		})
+		return
+	}
+
+	if len(blocked) > 0 && c.Query("cascade") != "true" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Existem ocorrências com agendamentos associados; use cascade=true para excluí-los junto",
+			Data:    blocked,
+		})
+		return
+	}
+
+	if err := deleteEventsCascade(tx, eventIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir eventos da série",
+		})
+		return
+	}
+
+	// Excluir a série inteira ou encerrá-la antes da primeira ocorrência removida
+	if from == nil || !from.After(seriesStart) {
+		_, err = tx.Exec(context.Background(), "DELETE FROM event_series WHERE id = $1", seriesID)
+	} else {
+		_, err = tx.Exec(context.Background(), "UPDATE event_series SET until = $1 WHERE id = $2",
+			from.Add(-time.Second), seriesID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar série de eventos",
+		})
+		return
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Eventos da série excluídos com sucesso (" + strconv.Itoa(len(eventIDs)) + " eventos)",
+	})
+}
+
+// deleteEventsCascade remove eventos junto com pedidos de troca, agendamentos e necessidades de escala
+func deleteEventsCascade(tx pgx.Tx, eventIDs []int) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	statements := []string{
+		`DELETE FROM swap_requests
+		 WHERE requestor_schedule_id IN (SELECT id FROM schedules WHERE event_id = ANY($1))
+		    OR target_schedule_id IN (SELECT id FROM schedules WHERE event_id = ANY($1))`,
+		"UPDATE schedules SET trainee_partner_id = NULL WHERE event_id = ANY($1)",
+		"DELETE FROM schedules WHERE event_id = ANY($1)",
+		"DELETE FROM staffing_requirements WHERE event_id = ANY($1)",
+		"DELETE FROM events WHERE id = ANY($1)",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(context.Background(), statement, eventIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEventSeriesRequest valida a regra e as datas da série, aplicando o horizonte padrão
+func validateEventSeriesRequest(seriesRequest *models.EventSeriesRequest) string {
+	rule, err := utils.ParseRecurrenceRule(seriesRequest.RRule)
+	if err != nil {
+		return "Regra de recorrência inválida: " + err.Error()
+	}
+	seriesRequest.RRule = rule.String()
+
+	if seriesRequest.Until != nil && seriesRequest.Until.Before(seriesRequest.StartDate) {
+		return "A data final da série deve ser posterior à data inicial"
+	}
+
+	if seriesRequest.HorizonDays == 0 {
+		seriesRequest.HorizonDays = defaultSeriesHorizonDays
+	}
+	if seriesRequest.DurationMinutes == 0 {
+		seriesRequest.DurationMinutes = defaultEventDurationMinutes
+	}
+
+	// A regra precisa alcançar o horizonte da série dentro do limite de períodos
+	horizon := truncateToDay(time.Now()).AddDate(0, 0, seriesRequest.HorizonDays+1)
+	if _, err := rule.Between(seriesRequest.StartDate, horizon, horizon); err != nil {
+		return "Regra de recorrência inválida: " + err.Error()
+	}
+
+	return ""
+}
+
+// scanEventSeries lê uma linha com as colunas de eventSeriesColumns
+func scanEventSeries(row pgx.Row) (models.EventSeries, error) {
+	var series models.EventSeries
+	err := row.Scan(&series.ID, &series.Title, &series.Description, &series.Location, &series.EventType,
+		&series.RRule, &series.StartDate, &series.DurationMinutes, &series.Until, &series.HorizonDays, &series.ShiftMinutes,
+		&series.Exdates, &series.CreatedAt)
+	return series, err
+}
+
+// wallClock descarta o fuso mantendo o horário de parede, como o Postgres faz ao gravar colunas timestamp
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// occurrenceKey identifica uma ocorrência pelo horário de parede, independente do fuso do time.Time
+func occurrenceKey(t time.Time) string {
+	return t.Format("2006-01-02 15:04")
+}
+
+// isValidSeriesScope verifica se o escopo de edição/exclusão de uma ocorrência é suportado
+func isValidSeriesScope(scope string) bool {
+	return scope == "this" || scope == "following" || scope == "all"
+}
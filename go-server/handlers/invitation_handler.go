@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/crypto/bcrypt"
+	"volunteer-scheduler/config"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+// invitationColumns são as colunas lidas por scanInvitation (convite i, time t, papel r)
+const invitationColumns = `i.id, i.email, i.team_id, t.name, i.role_id, r.name, i.is_trainee, i.invited_by_id,
+	CASE WHEN i.accepted_at IS NOT NULL THEN 'accepted'
+	     WHEN i.revoked_at IS NOT NULL THEN 'revoked'
+	     WHEN i.expires_at <= NOW() THEN 'expired'
+	     ELSE 'pending' END,
+	i.expires_at, i.accepted_at, i.created_at`
+
+// invitationJoins liga o convite ao time e ao papel
+const invitationJoins = ` FROM invitations i
+	JOIN teams t ON i.team_id = t.id
+	JOIN roles r ON i.role_id = r.id`
+
+// GetInvitations retorna os convites dos times que o usuário logado pode gerenciar
+func GetInvitations(c *gin.Context) {
+	scope, ok := currentTeamScope(c)
+	if !ok {
+		return
+	}
+
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT "+invitationColumns+invitationJoins+" ORDER BY i.created_at DESC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar convites",
+		})
+		return
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar convites",
+			})
+			return
+		}
+		if scope.allows(invitation.TeamID) {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    invitations,
+	})
+}
+
+// CreateInvitation cria um convite para um e-mail entrar em um time com um papel e envia o link
+func CreateInvitation(c *gin.Context) {
+	var invitationRequest models.InvitationRequest
+	if err := c.ShouldBindJSON(&invitationRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Verificar se o papel pertence ao time
+	var roleTeamMatch bool
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND team_id = $2)",
+		invitationRequest.RoleID, invitationRequest.TeamID).Scan(&roleTeamMatch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar associação papel-time",
+		})
+		return
+	}
+
+	if !roleTeamMatch {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "O papel selecionado não pertence ao time selecionado",
+		})
+		return
+	}
+
+	// Líderes só podem convidar para os times que lideram
+	if !requireTeamScope(c, invitationRequest.TeamID) {
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar convite",
+		})
+		return
+	}
+
+	var invitedByID *int
+	if userID, exists := c.Get("userID"); exists {
+		if id, ok := userID.(int); ok {
+			invitedByID = &id
+		}
+	}
+
+	var invitationID int
+	err = db.DB.QueryRow(context.Background(),
+		`INSERT INTO invitations (email, team_id, role_id, is_trainee, token_hash, invited_by_id, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW() + make_interval(days => $7))
+		 RETURNING id`,
+		strings.TrimSpace(invitationRequest.Email), invitationRequest.TeamID, invitationRequest.RoleID,
+		invitationRequest.IsTrainee, utils.HashToken(token), invitedByID, AppConfig.InvitationTTLDays).Scan(&invitationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar convite",
+		})
+		return
+	}
+
+	invitation, err := scanInvitation(db.DB.QueryRow(context.Background(),
+		"SELECT "+invitationColumns+invitationJoins+" WHERE i.id = $1", invitationID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar convite",
+		})
+		return
+	}
+
+	message := "Convite criado com sucesso"
+	if err := sendInvitation(AppConfig, invitation, token); err != nil {
+		log.Printf("Erro ao enviar convite %d: %v", invitation.ID, err)
+		message = "Convite criado, mas não foi possível enviá-lo por e-mail"
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    invitation,
+	})
+}
+
+// RevokeInvitation cancela um convite ainda não aceito
+func RevokeInvitation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var teamID int
+	var accepted bool
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT team_id, accepted_at IS NOT NULL FROM invitations WHERE id = $1", id).Scan(&teamID, &accepted)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Convite não encontrado",
+		})
+		return
+	}
+
+	// Líderes só podem cancelar convites dos times que lideram
+	if !requireTeamScope(c, teamID) {
+		return
+	}
+
+	if accepted {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Não é possível cancelar um convite já aceito",
+		})
+		return
+	}
+
+	_, err = db.DB.Exec(context.Background(),
+		"UPDATE invitations SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao cancelar convite",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Convite cancelado com sucesso",
+	})
+}
+
+// GetInvitationByToken retorna os dados de um convite pendente para a tela de cadastro
+func GetInvitationByToken(c *gin.Context) {
+	invitation, err := scanInvitation(db.DB.QueryRow(context.Background(),
+		"SELECT "+invitationColumns+invitationJoins+" WHERE i.token_hash = $1", utils.HashToken(c.Param("token"))))
+	if err != nil || invitation.Status != "pending" {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Convite inválido ou expirado",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    invitation,
+	})
+}
+
+// AcceptInvitation cria, em uma única transação, o usuário (papel "volunteer") e o voluntário do convite
+func AcceptInvitation(c *gin.Context) {
+	var acceptRequest models.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&acceptRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos (a senha deve ter ao menos 6 caracteres)",
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(acceptRequest.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao processar senha",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Bloquear o convite para que não seja aceito duas vezes
+	var invitationID, teamID, roleID int
+	var email string
+	var isTrainee bool
+	err = tx.QueryRow(context.Background(),
+		`SELECT id, email, team_id, role_id, is_trainee FROM invitations
+		 WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		 FOR UPDATE`,
+		utils.HashToken(acceptRequest.Token)).Scan(&invitationID, &email, &teamID, &roleID, &isTrainee)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Convite inválido ou expirado",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar convite",
+		})
+		return
+	}
+
+	// Verificar se o nome de usuário já existe
+	var exists bool
+	err = tx.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", acceptRequest.Username).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar nome de usuário",
+		})
+		return
+	}
+
+	if exists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Nome de usuário já existente",
+		})
+		return
+	}
+
+	var user models.User
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO users (username, password, name, email, role) VALUES ($1, $2, $3, $4, 'volunteer')
+		 RETURNING id, username, name, email, role, active, created_at`,
+		acceptRequest.Username, string(hashedPassword), acceptRequest.Name, email).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar usuário",
+		})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(),
+		"INSERT INTO volunteers (user_id, team_id, role_id, is_trainee) VALUES ($1, $2, $3, $4)",
+		user.ID, teamID, roleID, isTrainee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar voluntário",
+		})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(),
+		"UPDATE invitations SET accepted_at = NOW(), accepted_user_id = $1 WHERE id = $2", user.ID, invitationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar convite",
+		})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Cadastro concluído com sucesso",
+		Data:    user,
+	})
+}
+
+// sendInvitation envia o link do convite para o e-mail convidado
+func sendInvitation(cfg config.Config, invitation models.Invitation, token string) error {
+	link := cfg.AppBaseURL + "/invite?token=" + url.QueryEscape(token)
+	return MessageSender.Send(utils.Message{
+		To:      invitation.Email,
+		Subject: "Convite para o time " + invitation.TeamName,
+		Body: "Olá!\n\n" +
+			"Você foi convidado para servir no time " + invitation.TeamName + " como " + invitation.RoleName + ".\n" +
+			"Para criar sua conta, acesse o link abaixo (válido por " + strconv.Itoa(cfg.InvitationTTLDays) + " dias):\n\n" +
+			link,
+	})
+}
+
+// scanInvitation lê uma linha com invitationColumns
+func scanInvitation(row pgx.Row) (models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(&invitation.ID, &invitation.Email, &invitation.TeamID, &invitation.TeamName,
+		&invitation.RoleID, &invitation.RoleName, &invitation.IsTrainee, &invitation.InvitedByID,
+		&invitation.Status, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.CreatedAt)
+	return invitation, err
+}
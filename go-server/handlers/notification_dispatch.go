@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+// Status de uma entrega de notificação por canal externo
+const (
+	deliveryStatusPending = "pending"
+	deliveryStatusSent    = "sent"
+	// Confirmada pelo provedor no callback de status (SMS/WhatsApp)
+	deliveryStatusDelivered = "delivered"
+	deliveryStatusFailed    = "failed"
+)
+
+// NotificationDelivery é uma notificação a ser entregue a um usuário por um canal externo
+type NotificationDelivery struct {
+	ID             int
+	NotificationID int
+	Channel        string
+	Attempts       int
+	Type           string
+	Title          string
+	Message        string
+	UserID         int
+	UserName       string
+	UserEmail      string
+	UserPhone      string
+}
+
+// NotificationChannel entrega notificações por um meio externo ao aplicativo (e-mail, SMS, WhatsApp).
+// Deliver devolve o identificador da mensagem no provedor, quando houver, para o acompanhamento do status.
+type NotificationChannel interface {
+	Deliver(delivery NotificationDelivery) (string, error)
+}
+
+// errNoRecipient indica que o usuário não tem endereço para o canal; a entrega falha sem novas tentativas
+var errNoRecipient = errors.New("usuário sem endereço cadastrado para este canal")
+
+// NotificationChannels são os canais disponíveis, pelo nome gravado em notification_preferences.channel
+var NotificationChannels = map[string]NotificationChannel{
+	"email":                   emailChannel{},
+	utils.TextChannelSMS:      textChannel{Channel: utils.TextChannelSMS},
+	utils.TextChannelWhatsApp: textChannel{Channel: utils.TextChannelWhatsApp},
+}
+
+// TextProvider envia as mensagens de SMS e WhatsApp (substituído em main.go conforme a configuração)
+var TextProvider utils.TextProvider = &utils.FakeTextProvider{}
+
+// DisableTextChannels remove os canais de SMS e WhatsApp (produção sem provedor real). Eles deixam de
+// poder ser ativados, e entregas já enfileiradas para eles falham após as tentativas.
+func DisableTextChannels() {
+	delete(NotificationChannels, utils.TextChannelSMS)
+	delete(NotificationChannels, utils.TextChannelWhatsApp)
+}
+
+// notificationChannelNames lista os canais disponíveis em ordem alfabética
+func notificationChannelNames() []string {
+	names := make([]string, 0, len(NotificationChannels))
+	for name := range NotificationChannels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// createNotification grava a notificação no aplicativo e, na mesma instrução (e transação, se houver),
+// enfileira uma entrega para cada canal externo que o usuário ativou e avisa o fluxo SSE de todas as
+// instâncias (o NOTIFY só é entregue na confirmação da transação)
+func createNotification(q rowQuerier, userID int, title, message, notificationType string) (models.Notification, error) {
+	var notification models.Notification
+	err := q.QueryRow(context.Background(),
+		`WITH n AS (
+			INSERT INTO notifications (user_id, title, message, type)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, user_id, title, message, type, read, created_at
+		), d AS (
+			INSERT INTO notification_deliveries (notification_id, user_id, channel)
+			SELECT n.id, n.user_id, p.channel
+			FROM n JOIN notification_preferences p ON p.user_id = n.user_id AND p.enabled = true
+		), event AS (
+			SELECT pg_notify($5, json_build_object('userId', n.user_id, 'notificationId', n.id)::text) FROM n
+		)
+		SELECT id, user_id, title, message, type, read, created_at FROM n, event`,
+		userID, title, message, notificationType, notificationEventsChannel).
+		Scan(&notification.ID, &notification.UserID, &notification.Title,
+			&notification.Message, &notification.Type, &notification.Read, &notification.CreatedAt)
+	return notification, err
+}
+
+// deliveryClaimMinutes é o prazo de uma entrega reservada por uma instância: se o envio não for registrado
+// nesse tempo (instância reiniciada no meio do envio), a entrega volta a ficar disponível
+const deliveryClaimMinutes = 10
+
+// deliveryResult é o resultado de uma tentativa de entrega, gravado por recordDeliveryResult
+type deliveryResult struct {
+	Status            string
+	ProviderMessageID string
+	Error             string
+	// Espera antes da próxima tentativa, quando a entrega continua pendente
+	RetryMinutes int
+}
+
+// StartNotificationDispatcher envia periodicamente as entregas pendentes. Várias instâncias podem rodar
+// ao mesmo tempo: cada entrega é reservada (SKIP LOCKED) por quem vai enviá-la.
+func StartNotificationDispatcher(interval time.Duration) {
+	go func() {
+		for {
+			if sent, err := DispatchNotifications(); err != nil {
+				log.Printf("Erro ao enviar notificações: %v", err)
+			} else if sent > 0 {
+				log.Printf("Notificações enviadas: %d", sent)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// DispatchNotifications envia um lote de entregas pendentes. Falhas são reagendadas com espera crescente
+// (1, 2, 4... minutos, até 1 hora) e marcadas como falhas após NOTIFICATION_MAX_ATTEMPTS tentativas.
+// As entregas são reservadas e a tentativa contada antes do envio, sem manter bloqueios durante as chamadas
+// aos provedores; o resultado de cada uma é gravado logo após o envio.
+func DispatchNotifications() (int, error) {
+	deliveries, err := claimNotificationDeliveries(50)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, delivery := range deliveries {
+		result := deliverNotification(delivery, AppConfig.NotificationMaxAttempts)
+		if result.Status == deliveryStatusSent {
+			sent++
+		}
+		if err := recordDeliveryResult(delivery.ID, result); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// claimNotificationDeliveries reserva até limit entregas vencidas, adiando a próxima tentativa por
+// deliveryClaimMinutes e contando a tentativa, em uma única instrução
+func claimNotificationDeliveries(limit int) ([]NotificationDelivery, error) {
+	rows, err := db.DB.Query(context.Background(),
+		`WITH claimed AS (
+			UPDATE notification_deliveries
+			SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(mins => $2)
+			WHERE id IN (
+				SELECT id FROM notification_deliveries
+				WHERE status = $1 AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at, id
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, notification_id, user_id, channel, attempts
+		)
+		SELECT d.id, d.notification_id, d.channel, d.attempts, n.type, n.title, n.message, u.id, u.name, u.email,
+		       COALESCE(u.phone, '')
+		FROM claimed d
+		JOIN notifications n ON d.notification_id = n.id
+		JOIN users u ON d.user_id = u.id
+		ORDER BY d.id`, deliveryStatusPending, deliveryClaimMinutes, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []NotificationDelivery
+	for rows.Next() {
+		var delivery NotificationDelivery
+		if err := rows.Scan(&delivery.ID, &delivery.NotificationID, &delivery.Channel, &delivery.Attempts,
+			&delivery.Type, &delivery.Title, &delivery.Message, &delivery.UserID, &delivery.UserName,
+			&delivery.UserEmail, &delivery.UserPhone); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// deliverNotification envia a entrega pelo seu canal e decide a nova situação. delivery.Attempts já inclui
+// a tentativa atual.
+func deliverNotification(delivery NotificationDelivery, maxAttempts int) deliveryResult {
+	var providerMessageID string
+	err := errors.New("canal não configurado")
+	if channel, ok := NotificationChannels[delivery.Channel]; ok {
+		providerMessageID, err = channel.Deliver(delivery)
+	}
+
+	switch {
+	case err == nil:
+		return deliveryResult{Status: deliveryStatusSent, ProviderMessageID: providerMessageID}
+	case errors.Is(err, errNoRecipient) || delivery.Attempts >= maxAttempts:
+		return deliveryResult{Status: deliveryStatusFailed, Error: err.Error()}
+	default:
+		return deliveryResult{
+			Status:       deliveryStatusPending,
+			Error:        err.Error(),
+			RetryMinutes: deliveryRetryMinutes(delivery.Attempts),
+		}
+	}
+}
+
+// recordDeliveryResult grava o resultado da tentativa
+func recordDeliveryResult(deliveryID int, result deliveryResult) error {
+	var err error
+	switch result.Status {
+	case deliveryStatusSent:
+		_, err = db.DB.Exec(context.Background(),
+			`UPDATE notification_deliveries
+			 SET status = $1, sent_at = NOW(), last_error = NULL, provider_message_id = NULLIF($2, '')
+			 WHERE id = $3`, deliveryStatusSent, result.ProviderMessageID, deliveryID)
+	case deliveryStatusFailed:
+		_, err = db.DB.Exec(context.Background(),
+			"UPDATE notification_deliveries SET status = $1, last_error = $2 WHERE id = $3",
+			deliveryStatusFailed, result.Error, deliveryID)
+	default:
+		_, err = db.DB.Exec(context.Background(),
+			`UPDATE notification_deliveries
+			 SET last_error = $1, next_attempt_at = NOW() + make_interval(mins => $2)
+			 WHERE id = $3`, result.Error, result.RetryMinutes, deliveryID)
+	}
+	return err
+}
+
+// deliveryRetryMinutes calcula a espera antes da próxima tentativa (1, 2, 4... minutos, até 1 hora)
+func deliveryRetryMinutes(attempts int) int {
+	minutes := 1
+	for i := 1; i < attempts && minutes < 60; i++ {
+		minutes *= 2
+	}
+	if minutes > 60 {
+		minutes = 60
+	}
+	return minutes
+}
+
+// emailChannel envia as notificações por e-mail usando MessageSender. Em produção o servidor só inicia com
+// MAIL_SENDER=smtp, então uma entrega "sent" foi de fato aceita pelo servidor SMTP.
+type emailChannel struct{}
+
+// Deliver implementa NotificationChannel
+func (emailChannel) Deliver(delivery NotificationDelivery) (string, error) {
+	if delivery.UserEmail == "" {
+		return "", errNoRecipient
+	}
+
+	baseURL := AppConfig.AppBaseURL
+	subject, body, err := utils.RenderNotificationEmail(delivery.Type, utils.NotificationEmailData{
+		Name:            delivery.UserName,
+		Title:           delivery.Title,
+		Message:         delivery.Message,
+		Link:            baseURL + "/notifications",
+		PreferencesLink: baseURL + "/notifications/preferences",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return "", MessageSender.Send(utils.Message{
+		To:      delivery.UserEmail,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+// textChannel envia as notificações como mensagem curta por SMS ou WhatsApp usando TextProvider
+type textChannel struct {
+	Channel string
+}
+
+// Deliver implementa NotificationChannel
+func (ch textChannel) Deliver(delivery NotificationDelivery) (string, error) {
+	if delivery.UserPhone == "" {
+		return "", errNoRecipient
+	}
+
+	text, err := utils.RenderNotificationText(delivery.Type, utils.NotificationEmailData{
+		Name:    delivery.UserName,
+		Title:   delivery.Title,
+		Message: delivery.Message,
+		Link:    AppConfig.AppBaseURL + "/notifications",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return TextProvider.SendText(utils.TextMessage{
+		Channel: ch.Channel,
+		To:      delivery.UserPhone,
+		Body:    text,
+	})
+}
+
+// UpdateNotificationDeliveryStatus recebe do provedor de SMS/WhatsApp a confirmação de entrega ou a falha
+// das mensagens. O provedor precisa enviar o token TEXT_STATUS_CALLBACK_TOKEN no parâmetro "token".
+func UpdateNotificationDeliveryStatus(c *gin.Context) {
+	if !validTextCallbackToken(c.Query("token")) {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Token inválido",
+		})
+		return
+	}
+
+	statuses, err := utils.ParseTextStatusCallback(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	for _, status := range statuses {
+		if status.Status == utils.TextStatusDelivered {
+			_, err = db.DB.Exec(context.Background(),
+				`UPDATE notification_deliveries SET status = $1, delivered_at = COALESCE(delivered_at, NOW())
+				 WHERE provider_message_id = $2 AND status IN ($3, $1)`,
+				deliveryStatusDelivered, status.MessageID, deliveryStatusSent)
+		} else {
+			_, err = db.DB.Exec(context.Background(),
+				`UPDATE notification_deliveries SET status = $1, last_error = NULLIF($2, '')
+				 WHERE provider_message_id = $3 AND status = $4`,
+				deliveryStatusFailed, status.Error, status.MessageID, deliveryStatusSent)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao atualizar entrega",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+	})
+}
+
+// VerifyNotificationDeliveryWebhook responde à verificação da assinatura do webhook da WhatsApp Cloud API,
+// devolvendo hub.challenge quando hub.verify_token confere com TEXT_STATUS_CALLBACK_TOKEN
+func VerifyNotificationDeliveryWebhook(c *gin.Context) {
+	if c.Query("hub.mode") != "subscribe" || !validTextCallbackToken(c.Query("hub.verify_token")) {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Token inválido",
+		})
+		return
+	}
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// validTextCallbackToken compara o token recebido com TEXT_STATUS_CALLBACK_TOKEN (sem token configurado,
+// os callbacks são recusados)
+func validTextCallbackToken(token string) bool {
+	expected := AppConfig.TextStatusCallbackToken
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
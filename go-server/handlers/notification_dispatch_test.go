@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeChannel é um canal de notificação controlado pelos testes
+type fakeChannel struct {
+	messageID  string
+	err        error
+	deliveries []NotificationDelivery
+}
+
+func (ch *fakeChannel) Deliver(delivery NotificationDelivery) (string, error) {
+	ch.deliveries = append(ch.deliveries, delivery)
+	return ch.messageID, ch.err
+}
+
+// useNotificationChannels troca os canais disponíveis durante o teste
+func useNotificationChannels(t *testing.T, channels map[string]NotificationChannel) {
+	t.Helper()
+	previous := NotificationChannels
+	NotificationChannels = channels
+	t.Cleanup(func() { NotificationChannels = previous })
+}
+
+func TestDeliverNotification(t *testing.T) {
+	providerErr := errors.New("provedor indisponível")
+
+	tests := []struct {
+		name        string
+		channel     string
+		err         error
+		attempts    int
+		wantStatus  string
+		wantError   string
+		wantRetry   int
+		wantMessage string
+	}{
+		{"enviada", "fake", nil, 1, deliveryStatusSent, "", 0, "msg-1"},
+		{"primeira falha", "fake", providerErr, 1, deliveryStatusPending, providerErr.Error(), 1, ""},
+		{"terceira falha", "fake", providerErr, 3, deliveryStatusPending, providerErr.Error(), 4, ""},
+		{"última tentativa", "fake", providerErr, 5, deliveryStatusFailed, providerErr.Error(), 0, ""},
+		{"além do limite", "fake", providerErr, 6, deliveryStatusFailed, providerErr.Error(), 0, ""},
+		{"sem endereço", "fake", errNoRecipient, 1, deliveryStatusFailed, errNoRecipient.Error(), 0, ""},
+		{"canal desconhecido", "pombo", nil, 1, deliveryStatusPending, "canal não configurado", 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &fakeChannel{err: tt.err}
+			if tt.err == nil {
+				channel.messageID = "msg-1"
+			}
+			useNotificationChannels(t, map[string]NotificationChannel{"fake": channel})
+
+			delivery := NotificationDelivery{ID: 7, Channel: tt.channel, Attempts: tt.attempts, Title: "Escala"}
+			result := deliverNotification(delivery, 5)
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("status %q, esperado %q", result.Status, tt.wantStatus)
+			}
+			if result.Error != tt.wantError {
+				t.Errorf("erro %q, esperado %q", result.Error, tt.wantError)
+			}
+			if result.RetryMinutes != tt.wantRetry {
+				t.Errorf("nova tentativa em %d minutos, esperado %d", result.RetryMinutes, tt.wantRetry)
+			}
+			if result.ProviderMessageID != tt.wantMessage {
+				t.Errorf("identificador %q, esperado %q", result.ProviderMessageID, tt.wantMessage)
+			}
+			if tt.channel == "fake" && (len(channel.deliveries) != 1 || channel.deliveries[0].ID != delivery.ID) {
+				t.Errorf("o canal recebeu %v, esperado a entrega %d", channel.deliveries, delivery.ID)
+			}
+		})
+	}
+}
+
+func TestDeliveryRetryMinutes(t *testing.T) {
+	tests := []struct {
+		attempts int
+		minutes  int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 4},
+		{6, 32},
+		{7, 60},
+		{20, 60},
+	}
+
+	for _, tt := range tests {
+		if got := deliveryRetryMinutes(tt.attempts); got != tt.minutes {
+			t.Errorf("deliveryRetryMinutes(%d) = %d, esperado %d", tt.attempts, got, tt.minutes)
+		}
+	}
+}
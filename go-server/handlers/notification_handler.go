@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"volunteer-scheduler/db"
@@ -117,6 +118,8 @@ func MarkNotificationAsRead(c *gin.Context) {
 		return
 	}
 
+	notifyUnreadCountChanged(userID)
+
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
 		Message: "Notificação marcada como lida",
@@ -189,16 +192,9 @@ func CreateNotification(c *gin.Context) {
 		return
 	}
 
-	// Criar notificação
-	var notification models.Notification
-	err = db.DB.QueryRow(context.Background(),
-		`INSERT INTO notifications (user_id, title, message, type) 
-		 VALUES ($1, $2, $3, $4) 
-		 RETURNING id, user_id, title, message, type, read, created_at`,
-		notificationRequest.UserID, notificationRequest.Title, 
-		notificationRequest.Message, notificationRequest.Type).
-		Scan(&notification.ID, &notification.UserID, &notification.Title, 
-			&notification.Message, &notification.Type, &notification.Read, &notification.CreatedAt)
+	// Criar notificação (e enfileirar as entregas nos canais ativados pelo usuário)
+	notification, err := createNotification(db.DB, notificationRequest.UserID, notificationRequest.Title,
+		notificationRequest.Message, notificationRequest.Type)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
@@ -267,6 +263,8 @@ func DeleteNotification(c *gin.Context) {
 		return
 	}
 
+	notifyUnreadCountChanged(userID)
+
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
 		Message: "Notificação excluída com sucesso",
@@ -298,8 +296,131 @@ func MarkAllNotificationsAsRead(c *gin.Context) {
 		return
 	}
 
+	notifyUnreadCountChanged(userID)
+
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Success: true,
 		Message: "Todas as notificações foram marcadas como lidas",
 	})
-}
\ No newline at end of file
+}
+// GetNotificationPreferences retorna, para cada canal externo disponível, se o usuário logado o ativou
+func GetNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não autenticado",
+		})
+		return
+	}
+
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT channel, enabled FROM notification_preferences WHERE user_id = $1", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar preferências de notificação",
+		})
+		return
+	}
+	defer rows.Close()
+
+	enabled := map[string]bool{}
+	for rows.Next() {
+		var channel string
+		var value bool
+		if err := rows.Scan(&channel, &value); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar preferências de notificação",
+			})
+			return
+		}
+		enabled[channel] = value
+	}
+
+	// Canais sem preferência gravada ficam desativados (as notificações externas são opcionais)
+	preferences := []models.NotificationPreference{}
+	for _, channel := range notificationChannelNames() {
+		preferences = append(preferences, models.NotificationPreference{
+			Channel: channel,
+			Enabled: enabled[channel],
+		})
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    preferences,
+	})
+}
+
+// UpdateNotificationPreference ativa ou desativa um canal externo de notificação para o usuário logado
+func UpdateNotificationPreference(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req models.NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if _, ok := NotificationChannels[req.Channel]; !ok {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Canal de notificação inválido. Canais disponíveis: " + strings.Join(notificationChannelNames(), ", "),
+		})
+		return
+	}
+
+	// SMS e WhatsApp precisam de um telefone cadastrado no perfil
+	if _, isText := NotificationChannels[req.Channel].(textChannel); isText && *req.Enabled {
+		var hasPhone bool
+		err := db.DB.QueryRow(context.Background(),
+			"SELECT phone IS NOT NULL FROM users WHERE id = $1", userID).Scan(&hasPhone)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao verificar telefone",
+			})
+			return
+		}
+		if !hasPhone {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Cadastre um telefone no perfil (PUT /api/profile/phone) antes de ativar este canal",
+			})
+			return
+		}
+	}
+
+	var preference models.NotificationPreference
+	err := db.DB.QueryRow(context.Background(),
+		`INSERT INTO notification_preferences (user_id, channel, enabled, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (user_id, channel) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()
+		 RETURNING channel, enabled`,
+		userID, req.Channel, *req.Enabled).Scan(&preference.Channel, &preference.Enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar preferência de notificação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Preferência de notificação atualizada com sucesso",
+		Data:    preference,
+	})
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// notificationEventsChannel é o canal do LISTEN/NOTIFY do Postgres pelo qual todas as instâncias ficam
+// sabendo de notificações novas e de mudanças no número de não lidas
+const notificationEventsChannel = "notification_events"
+
+// notificationStreamHeartbeat mantém a conexão SSE aberta em proxies que encerram conexões ociosas
+const notificationStreamHeartbeat = 25 * time.Second
+
+// notificationEvent é um evento enviado pelo fluxo SSE ("notification" ou "unread_count")
+type notificationEvent struct {
+	Name string
+	Data interface{}
+}
+
+// notificationEventPayload é o conteúdo do NOTIFY; sem notificationId, só o número de não lidas mudou
+type notificationEventPayload struct {
+	UserID         int  `json:"userId"`
+	NotificationID *int `json:"notificationId"`
+}
+
+// notificationHub distribui os eventos às conexões SSE abertas nesta instância, por usuário
+type notificationHub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan notificationEvent]struct{}
+}
+
+// streamHub é o hub das conexões SSE desta instância, alimentado por StartNotificationListener
+var streamHub = &notificationHub{subscribers: map[int]map[chan notificationEvent]struct{}{}}
+
+// subscribe registra uma conexão do usuário; a função devolvida a remove
+func (h *notificationHub) subscribe(userID int) (chan notificationEvent, func()) {
+	events := make(chan notificationEvent, 16)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan notificationEvent]struct{}{}
+	}
+	h.subscribers[userID][events] = struct{}{}
+	h.mu.Unlock()
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers[userID], events)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+	}
+}
+
+// hasSubscribers indica se o usuário tem alguma conexão aberta nesta instância
+func (h *notificationHub) hasSubscribers(userID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[userID]) > 0
+}
+
+// userIDs lista os usuários com conexões abertas
+func (h *notificationHub) userIDs() []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]int, 0, len(h.subscribers))
+	for userID := range h.subscribers {
+		ids = append(ids, userID)
+	}
+	return ids
+}
+
+// publish entrega o evento às conexões do usuário. Conexões lentas, com a fila cheia, perdem o evento;
+// o próximo unread_count as deixa em dia.
+func (h *notificationHub) publish(userID int, event notificationEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for events := range h.subscribers[userID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// StreamNotifications mantém aberto um fluxo Server-Sent Events com as notificações novas ("notification")
+// e o número de não lidas ("unread_count") do usuário logado
+func StreamNotifications(c *gin.Context) {
+	value, exists := c.Get("userID")
+	userID, ok := value.(int)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não autenticado",
+		})
+		return
+	}
+
+	events, unsubscribe := streamHub.subscribe(userID)
+	defer unsubscribe()
+
+	count, err := countUnreadNotifications(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao contar notificações não lidas",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent("unread_count", map[string]int{"count": count})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(notificationStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			c.SSEvent(event.Name, event.Data)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StartNotificationListener escuta os eventos de notificação do Postgres e os repassa às conexões SSE
+// desta instância, reconectando em caso de falha
+func StartNotificationListener() {
+	go func() {
+		for {
+			if err := listenNotificationEvents(); err != nil {
+				log.Printf("Erro ao escutar eventos de notificação: %v", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+}
+
+// listenNotificationEvents ocupa uma conexão do pool com LISTEN até que ela falhe
+func listenNotificationEvents() error {
+	conn, err := db.DB.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	defer conn.Exec(context.Background(), "UNLISTEN *")
+
+	if _, err := conn.Exec(context.Background(), "LISTEN "+notificationEventsChannel); err != nil {
+		return err
+	}
+
+	// Eventos emitidos enquanto não havia escuta se perderam: atualizar os contadores das conexões abertas
+	go func() {
+		for _, userID := range streamHub.userIDs() {
+			publishUnreadCount(userID)
+		}
+	}()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(context.Background())
+		if err != nil {
+			return err
+		}
+
+		var payload notificationEventPayload
+		if err := json.Unmarshal([]byte(notification.Payload), &payload); err != nil {
+			log.Printf("Evento de notificação inválido: %v", err)
+			continue
+		}
+		if !streamHub.hasSubscribers(payload.UserID) {
+			continue
+		}
+
+		if payload.NotificationID != nil {
+			var n models.Notification
+			err := db.DB.QueryRow(context.Background(),
+				`SELECT id, user_id, title, message, type, read, created_at
+				 FROM notifications WHERE id = $1`, *payload.NotificationID).
+				Scan(&n.ID, &n.UserID, &n.Title, &n.Message, &n.Type, &n.Read, &n.CreatedAt)
+			if err == nil {
+				streamHub.publish(payload.UserID, notificationEvent{Name: "notification", Data: n})
+			}
+		}
+		publishUnreadCount(payload.UserID)
+	}
+}
+
+// publishUnreadCount envia às conexões do usuário o número atual de notificações não lidas
+func publishUnreadCount(userID int) {
+	count, err := countUnreadNotifications(userID)
+	if err != nil {
+		log.Printf("Erro ao contar notificações não lidas: %v", err)
+		return
+	}
+	streamHub.publish(userID, notificationEvent{Name: "unread_count", Data: map[string]int{"count": count}})
+}
+
+// countUnreadNotifications conta as notificações não lidas do usuário
+func countUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read = false", userID).Scan(&count)
+	return count, err
+}
+
+// notifyUnreadCountChanged avisa todas as instâncias de que o número de não lidas do usuário mudou
+// (leitura ou exclusão). Falhas só são registradas: o fluxo é um complemento da consulta ao contador.
+func notifyUnreadCountChanged(userID interface{}) {
+	_, err := db.DB.Exec(context.Background(),
+		"SELECT pg_notify($1, json_build_object('userId', $2::int)::text)",
+		notificationEventsChannel, userID)
+	if err != nil {
+		log.Printf("Erro ao publicar evento de notificação: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// GetOpenShifts lista os turnos em aberto (solicitações de troca sem alvo) que o usuário logado pode assumir:
+// do mesmo time e função de um de seus voluntários, sem conflito de horário, sem regra de indisponibilidade
+// e respeitando o acompanhamento de trainees do agendamento
+func GetOpenShifts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	// Conflitos de horário e acompanhamento de trainees são filtrados na própria consulta
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT sr.id, s1.id, e.id, e.title, e.event_date, e.location, t.id, t.name, r.id, r.name,
+		        u1.name, COALESCE(sr.reason, ''), v.id, sr.created_at
+		 FROM swap_requests sr
+		 JOIN schedules s1 ON sr.requestor_schedule_id = s1.id
+		 JOIN events e ON s1.event_id = e.id
+		 JOIN volunteers v1 ON s1.volunteer_id = v1.id
+		 JOIN users u1 ON v1.user_id = u1.id
+		 JOIN teams t ON v1.team_id = t.id
+		 JOIN roles r ON v1.role_id = r.id
+		 JOIN volunteers v ON v.team_id = v1.team_id AND v.role_id = v1.role_id
+		 WHERE v.user_id = $1 AND v1.user_id != $1
+		 AND sr.status = $2 AND sr.target_schedule_id IS NULL AND sr.target_volunteer_id IS NULL
+		 AND s1.status != 'cancelled' AND e.event_date > NOW()
+		 AND COALESCE(v.is_trainee, false) = (s1.trainee_partner_id IS NOT NULL)
+		 AND v.id IS DISTINCT FROM s1.trainee_partner_id
+		 AND NOT EXISTS (
+		 	SELECT 1 FROM schedules s
+		 	JOIN volunteers vs ON s.volunteer_id = vs.id
+		 	JOIN events es ON s.event_id = es.id
+		 	WHERE vs.user_id = v.user_id AND s.status != 'cancelled' AND s.id != s1.id
+		 	AND es.event_date < e.event_date + make_interval(mins => e.duration_minutes + $3)
+		 	AND es.event_date + make_interval(mins => es.duration_minutes + $3) > e.event_date)
+		 ORDER BY e.event_date, sr.id`, userID, swapStatusPending, int(schedulingBuffer()/time.Minute))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar turnos em aberto",
+		})
+		return
+	}
+
+	var candidates []models.OpenShift
+	var volunteerIDs []int
+	for rows.Next() {
+		var shift models.OpenShift
+		if err := rows.Scan(&shift.SwapRequestID, &shift.ScheduleID, &shift.EventID, &shift.EventTitle,
+			&shift.EventDate, &shift.Location, &shift.TeamID, &shift.TeamName, &shift.RoleID, &shift.RoleName,
+			&shift.RequestorName, &shift.Reason, &shift.VolunteerID, &shift.CreatedAt); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar turnos em aberto",
+			})
+			return
+		}
+		candidates = append(candidates, shift)
+		if !containsInt(volunteerIDs, shift.VolunteerID) {
+			volunteerIDs = append(volunteerIDs, shift.VolunteerID)
+		}
+	}
+	rows.Close()
+
+	// Regras de disponibilidade dos voluntários do usuário, carregadas de uma vez
+	rules, err := queryAvailabilityRules(db.DB,
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules
+		 WHERE volunteer_id = ANY($1)`, volunteerIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar turnos em aberto",
+		})
+		return
+	}
+	rulesByVolunteer := map[int][]models.AvailabilityRule{}
+	for _, rule := range rules {
+		rulesByVolunteer[rule.VolunteerID] = append(rulesByVolunteer[rule.VolunteerID], rule)
+	}
+
+	// Mostrar apenas os turnos que o usuário conseguiria assumir
+	shifts := []models.OpenShift{}
+	for _, shift := range candidates {
+		if len(matchAvailabilityRules(rulesByVolunteer[shift.VolunteerID], shift.EventDate)) == 0 {
+			shifts = append(shifts, shift)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    shifts,
+	})
+}
+
+// ClaimOpenShift faz o usuário logado assumir um turno em aberto. A primeira reivindicação válida vira a
+// substituição pendente (aceita pelo voluntário, aguardando aprovação do líder); as seguintes são recusadas.
+func ClaimOpenShift(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	if swap.TargetScheduleID != nil || swap.TargetVolunteerID != nil {
+		message := "Esta solicitação de troca não é um turno em aberto"
+		if swap.TargetScheduleID == nil && swap.Status == swapStatusAcceptedByTarget {
+			message = "Este turno já foi assumido por outro voluntário"
+		}
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	if !requireSwapTransition(c, swap, swapStatusAcceptedByTarget, "assumir o turno de") {
+		return
+	}
+
+	// Voluntário do usuário logado no mesmo time e função do solicitante
+	var volunteerID int
+	var volunteerName string
+	err := tx.QueryRow(context.Background(),
+		`SELECT v.id, u.name
+		 FROM volunteers v
+		 JOIN users u ON v.user_id = u.id
+		 JOIN volunteers v1 ON v1.id = $2
+		 WHERE v.user_id = $1 AND v.user_id != v1.user_id AND u.active = true
+		 AND v.team_id = v1.team_id AND v.role_id = v1.role_id
+		 ORDER BY v.id
+		 LIMIT 1`, userID, swap.RequestorVolunteerID).Scan(&volunteerID, &volunteerName)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Success: false,
+			Error:   "Apenas voluntários do mesmo time e função podem assumir este turno",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar voluntário",
+		})
+		return
+	}
+
+	// As verificações abaixo valem até a confirmação: outras gravações para os mesmos usuários esperam
+	if err := lockScheduleUsers(tx, []int{volunteerID, swap.RequestorVolunteerID}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar conflitos de horário",
+		})
+		return
+	}
+
+	issue, violations, err := openShiftIssue(tx, volunteerID, swap.RequestorEventID, swap.RequestorScheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar conflitos de agendamento",
+		})
+		return
+	}
+	if issue != "" {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   issue,
+			Data:    violations,
+		})
+		return
+	}
+
+	// Trainees só assumem turnos com parceiro, e turnos de trainees só podem ser assumidos por trainees
+	if !requireScheduleTraineePairing(c, tx, volunteerID, swap.RequestorScheduleID) {
+		return
+	}
+
+	// O voluntário que assumiu passa a ser o alvo da troca
+	_, err = tx.Exec(context.Background(),
+		"UPDATE swap_requests SET target_volunteer_id = $1, status = $2 WHERE id = $3",
+		volunteerID, swapStatusAcceptedByTarget, swap.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar solicitação de troca",
+		})
+		return
+	}
+
+	// Avisar o líder, que precisa aprovar a substituição, e o solicitante
+	if !notifySwapUsers(c, tx, "Turno assumido",
+		volunteerName+" assumiu o turno do evento "+swap.RequestorEventTitle+" e aguarda aprovação do líder",
+		swap.RequestorLeaderID, &swap.RequestorUserID) {
+		return
+	}
+
+	finishSwapTransition(c, tx, swap.ID, "Turno assumido com sucesso; aguardando aprovação do líder")
+}
+
+// openShiftIssue explica por que o voluntário não pode assumir o turno do agendamento informado
+// (vazio se puder), devolvendo também as regras de disponibilidade violadas
+func openShiftIssue(q rowQuerier, volunteerID, eventID, scheduleID int) (string, []models.AvailabilityViolation, error) {
+	_, eventTitle, err := findSwapConflict(q, volunteerID, eventID, []int{scheduleID})
+	if err != nil {
+		return "", nil, err
+	}
+	if eventTitle != "" {
+		return "Conflito de horário: você já está escalado(a) em \"" + eventTitle + "\"", nil, nil
+	}
+
+	violations, err := checkAvailabilityViolations(q, eventID, volunteerID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(violations) > 0 {
+		return "Você está indisponível para este evento", violations, nil
+	}
+
+	return "", nil, nil
+}
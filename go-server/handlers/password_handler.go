@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/crypto/bcrypt"
+	"volunteer-scheduler/config"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+// MessageSender entrega as mensagens enviadas aos usuários (substituído em main.go conforme a configuração)
+var MessageSender utils.Sender = utils.LogSender{}
+
+// ChangePassword troca a senha do usuário autenticado, exigindo a senha atual.
+// As demais sessões do usuário são encerradas.
+func ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return
+	}
+
+	var passwordRequest models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&passwordRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos (a nova senha deve ter ao menos 6 caracteres)",
+		})
+		return
+	}
+
+	var currentHash string
+	err := db.DB.QueryRow(context.Background(), "SELECT password FROM users WHERE id = $1", userID).Scan(&currentHash)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(passwordRequest.CurrentPassword)) != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Senha atual incorreta",
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordRequest.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao processar senha",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "UPDATE users SET password = $1 WHERE id = $2", string(hashedPassword), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar senha",
+		})
+		return
+	}
+
+	// Manter apenas a sessão atual; as outras podem ter sido abertas com a senha antiga
+	sessionID, _ := c.Get("sessionID")
+	_, err = tx.Exec(context.Background(),
+		`UPDATE refresh_tokens SET revoked_at = NOW()
+		 WHERE user_id = $1 AND session_id IS DISTINCT FROM $2 AND revoked_at IS NULL`, userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao encerrar sessões do usuário",
+		})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Senha alterada com sucesso",
+	})
+}
+
+// RequestPasswordReset envia um link de redefinição de senha para o e-mail do usuário.
+// A resposta é sempre a mesma, para não revelar quais usuários existem.
+func RequestPasswordReset(c *gin.Context) {
+	var resetRequest models.PasswordResetRequest
+	if err := c.ShouldBindJSON(&resetRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	users, err := queryUsers(
+		`SELECT id, username, name, email, role, active, created_at FROM users
+		 WHERE active = true AND (username = $1 OR LOWER(email) = LOWER($1))`, resetRequest.Login)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar usuário",
+		})
+		return
+	}
+
+	for _, user := range users {
+		if err := sendPasswordReset(AppConfig, user); err != nil {
+			log.Printf("Erro ao enviar redefinição de senha para o usuário %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Se o usuário existir, um link de redefinição de senha foi enviado para o e-mail cadastrado",
+	})
+}
+
+// ResetPassword define uma nova senha usando um token de redefinição válido.
+// O token só pode ser usado uma vez e todas as sessões do usuário são encerradas.
+func ResetPassword(c *gin.Context) {
+	var confirmRequest models.PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&confirmRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos (a nova senha deve ter ao menos 6 caracteres)",
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(confirmRequest.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao processar senha",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var tokenID, userID int
+	err = tx.QueryRow(context.Background(),
+		`SELECT t.id, t.user_id FROM password_reset_tokens t
+		 JOIN users u ON t.user_id = u.id
+		 WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > NOW() AND u.active = true
+		 FOR UPDATE OF t`,
+		utils.HashToken(confirmRequest.Token)).Scan(&tokenID, &userID)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Link de redefinição inválido ou expirado",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar link de redefinição",
+		})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), "UPDATE users SET password = $1 WHERE id = $2", string(hashedPassword), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar senha",
+		})
+		return
+	}
+
+	// Invalidar este e qualquer outro link pendente do usuário
+	_, err = tx.Exec(context.Background(),
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao invalidar link de redefinição",
+		})
+		return
+	}
+
+	if err := revokeUserSessions(tx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao encerrar sessões do usuário",
+		})
+		return
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Senha redefinida com sucesso",
+	})
+}
+
+// sendPasswordReset gera um token de redefinição (gravado apenas como hash) e envia o link ao usuário.
+// Links anteriores ainda não usados deixam de valer.
+func sendPasswordReset(cfg config.Config, user models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(),
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", user.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		 VALUES ($1, $2, NOW() + make_interval(mins => $3))`,
+		user.ID, utils.HashToken(token), cfg.PasswordResetTTLMinutes)
+	if err != nil {
+		return err
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		return err
+	}
+
+	link := cfg.AppBaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return MessageSender.Send(utils.Message{
+		To:      user.Email,
+		Subject: "Redefinição de senha",
+		Body: "Olá, " + user.Name + "!\n\n" +
+			"Recebemos uma solicitação para redefinir a senha do usuário " + user.Username + ".\n" +
+			"Para escolher uma nova senha, acesse o link abaixo (válido por " +
+			strconv.Itoa(cfg.PasswordResetTTLMinutes) + " minutos):\n\n" + link + "\n\n" +
+			"Se você não fez esta solicitação, ignore esta mensagem.",
+	})
+}
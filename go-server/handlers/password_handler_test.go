@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"volunteer-scheduler/config"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+	"volunteer-scheduler/utils"
+)
+
+var testDBOnce sync.Once
+var testDBErr error
+
+// requireTestDB conecta ao banco de DATABASE_URL (já com o esquema aplicado) ou pula o teste
+func requireTestDB(t *testing.T) {
+	t.Helper()
+	testDBOnce.Do(func() {
+		testDBErr = db.InitDB()
+	})
+	if testDBErr != nil {
+		t.Skipf("banco de testes indisponível: %v", testDBErr)
+	}
+}
+
+// capturingSender guarda as mensagens enviadas pelos handlers
+type capturingSender struct {
+	messages []utils.Message
+}
+
+func (s *capturingSender) Send(msg utils.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+var resetLinkPattern = regexp.MustCompile(`\S+/reset-password\?token=\S+`)
+
+// resetTokenFromMessage extrai o token do link enviado por sendPasswordReset
+func resetTokenFromMessage(t *testing.T, msg utils.Message) string {
+	t.Helper()
+	link, err := url.Parse(resetLinkPattern.FindString(msg.Body))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("mensagem sem link de redefinição: %q", msg.Body)
+	}
+	return link.Query().Get("token")
+}
+
+// newPasswordResetUser cria um usuário ativo para o teste e o remove no final
+func newPasswordResetUser(t *testing.T) models.User {
+	t.Helper()
+	user := models.User{
+		Username: fmt.Sprintf("reset-test-%d", time.Now().UnixNano()),
+		Name:     "Teste de Redefinição",
+		Email:    "reset-test@example.com",
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-antiga"), bcrypt.MinCost)
+	err := db.DB.QueryRow(context.Background(),
+		`INSERT INTO users (username, password, name, email, role) VALUES ($1, $2, $3, $4, 'volunteer')
+		 RETURNING id`, user.Username, string(hash), user.Name, user.Email).Scan(&user.ID)
+	if err != nil {
+		t.Fatalf("criar usuário: %v", err)
+	}
+	t.Cleanup(func() {
+		db.DB.Exec(context.Background(), "DELETE FROM password_reset_tokens WHERE user_id = $1", user.ID)
+		db.DB.Exec(context.Background(), "DELETE FROM refresh_tokens WHERE user_id = $1", user.ID)
+		db.DB.Exec(context.Background(), "DELETE FROM users WHERE id = $1", user.ID)
+	})
+	return user
+}
+
+// requestPasswordResetToken envia um link de redefinição ao usuário e devolve o token recebido
+func requestPasswordResetToken(t *testing.T, user models.User) string {
+	t.Helper()
+	sender := &capturingSender{}
+	previous := MessageSender
+	MessageSender = sender
+	defer func() { MessageSender = previous }()
+
+	cfg := config.Config{AppBaseURL: "http://localhost:5000", PasswordResetTTLMinutes: 30}
+	if err := sendPasswordReset(cfg, user); err != nil {
+		t.Fatalf("sendPasswordReset: %v", err)
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("%d mensagens enviadas, esperado 1", len(sender.messages))
+	}
+	return resetTokenFromMessage(t, sender.messages[0])
+}
+
+// resetPassword chama ResetPassword e devolve o status HTTP
+func resetPassword(token, newPassword string) int {
+	gin.SetMode(gin.TestMode)
+	body, _ := json.Marshal(models.PasswordResetConfirmRequest{Token: token, NewPassword: newPassword})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/reset-password", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	ResetPassword(c)
+	return w.Code
+}
+
+func TestPasswordResetTokenStoredAsHash(t *testing.T) {
+	requireTestDB(t)
+	user := newPasswordResetUser(t)
+	token := requestPasswordResetToken(t, user)
+
+	var tokenHash string
+	var expiresOnTime bool
+	err := db.DB.QueryRow(context.Background(),
+		`SELECT token_hash, expires_at > NOW() + interval '29 minutes' AND expires_at <= NOW() + interval '30 minutes'
+		 FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, user.ID).Scan(&tokenHash, &expiresOnTime)
+	if err != nil {
+		t.Fatalf("buscar token gravado: %v", err)
+	}
+	if tokenHash == token {
+		t.Error("o token foi gravado sem hash")
+	}
+	if tokenHash != utils.HashToken(token) {
+		t.Errorf("hash gravado %q, esperado %q", tokenHash, utils.HashToken(token))
+	}
+	if !expiresOnTime {
+		t.Error("o token deveria expirar em 30 minutos (PasswordResetTTLMinutes)")
+	}
+}
+
+func TestResetPasswordSingleUse(t *testing.T) {
+	requireTestDB(t)
+	user := newPasswordResetUser(t)
+	token := requestPasswordResetToken(t, user)
+
+	if code := resetPassword(token, "senha-nova"); code != http.StatusOK {
+		t.Fatalf("primeiro uso: status %d, esperado %d", code, http.StatusOK)
+	}
+	var hash string
+	if err := db.DB.QueryRow(context.Background(),
+		"SELECT password FROM users WHERE id = $1", user.ID).Scan(&hash); err != nil {
+		t.Fatalf("buscar senha: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte("senha-nova")) != nil {
+		t.Error("a senha não foi alterada")
+	}
+
+	if code := resetPassword(token, "outra-senha"); code != http.StatusBadRequest {
+		t.Errorf("segundo uso: status %d, esperado %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestResetPasswordExpiredToken(t *testing.T) {
+	requireTestDB(t)
+	user := newPasswordResetUser(t)
+	token := requestPasswordResetToken(t, user)
+
+	_, err := db.DB.Exec(context.Background(),
+		"UPDATE password_reset_tokens SET expires_at = NOW() - interval '1 minute' WHERE token_hash = $1",
+		utils.HashToken(token))
+	if err != nil {
+		t.Fatalf("expirar token: %v", err)
+	}
+
+	if code := resetPassword(token, "senha-nova"); code != http.StatusBadRequest {
+		t.Errorf("token expirado: status %d, esperado %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestPasswordResetSupersedesPreviousLink(t *testing.T) {
+	requireTestDB(t)
+	user := newPasswordResetUser(t)
+	first := requestPasswordResetToken(t, user)
+	second := requestPasswordResetToken(t, user)
+
+	if code := resetPassword(first, "senha-nova"); code != http.StatusBadRequest {
+		t.Errorf("link anterior: status %d, esperado %d", code, http.StatusBadRequest)
+	}
+	if code := resetPassword(second, "senha-nova"); code != http.StatusOK {
+		t.Errorf("link mais recente: status %d, esperado %d", code, http.StatusOK)
+	}
+}
+
+func TestResetPasswordUnknownToken(t *testing.T) {
+	requireTestDB(t)
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		t.Fatalf("GenerateRandomToken: %v", err)
+	}
+	if code := resetPassword(token, "senha-nova"); code != http.StatusBadRequest {
+		t.Errorf("token desconhecido: status %d, esperado %d", code, http.StatusBadRequest)
+	}
+}
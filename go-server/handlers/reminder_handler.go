@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// maxReminderLeadHours limita a antecedência dos lembretes (30 dias)
+const maxReminderLeadHours = 720
+
+// dueReminder é um lembrete a ser enviado para um agendamento confirmado
+type dueReminder struct {
+	ScheduleID int
+	UserID     int
+	LeadHours  int
+	EventTitle string
+	EventDate  time.Time
+	Location   string
+	TeamName   string
+	RoleName   string
+}
+
+// StartReminderScheduler envia periodicamente os lembretes de escala que vencerem. Várias instâncias
+// podem rodar ao mesmo tempo: cada lembrete é registrado em schedule_reminders antes de ser criado.
+func StartReminderScheduler(interval time.Duration) {
+	go func() {
+		for {
+			if sent, err := SendDueReminders(); err != nil {
+				log.Printf("Erro ao enviar lembretes: %v", err)
+			} else if sent > 0 {
+				log.Printf("Lembretes enviados: %d", sent)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SendDueReminders cria uma notificação "reminder" para cada agendamento confirmado cujo evento começa
+// dentro da antecedência configurada no time (ou em REMINDER_LEAD_HOURS). Quando várias antecedências já
+// venceram (agendamento feito em cima da hora, servidor fora do ar), só a menor é enviada. Cada lembrete
+// é enviado uma vez por agendamento, antecedência e data do evento, mesmo após reinícios.
+func SendDueReminders() (int, error) {
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT s.id, v.user_id, lead.hours, e.title, e.event_date, COALESCE(e.location, ''), t.name, r.name
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN users u ON v.user_id = u.id
+		 JOIN teams t ON v.team_id = t.id
+		 JOIN roles r ON v.role_id = r.id
+		 JOIN events e ON s.event_id = e.id
+		 CROSS JOIN LATERAL (
+			SELECT MIN(h) AS hours
+			FROM unnest(COALESCE(t.reminder_lead_hours, $1::int[])) AS h
+			WHERE e.event_date - make_interval(hours => h) <= NOW()
+		 ) lead
+		 WHERE s.status = 'confirmed' AND u.active = true
+		 AND e.event_date > NOW() AND e.event_date <= NOW() + make_interval(hours => $2)
+		 AND lead.hours IS NOT NULL
+		 AND NOT EXISTS (
+			SELECT 1 FROM schedule_reminders sr
+			WHERE sr.schedule_id = s.id AND sr.lead_hours = lead.hours AND sr.event_date = e.event_date
+		 )
+		 ORDER BY e.event_date, s.id`,
+		AppConfig.ReminderLeadHours, maxReminderLeadHours)
+	if err != nil {
+		return 0, err
+	}
+	var reminders []dueReminder
+	for rows.Next() {
+		var reminder dueReminder
+		if err := rows.Scan(&reminder.ScheduleID, &reminder.UserID, &reminder.LeadHours, &reminder.EventTitle,
+			&reminder.EventDate, &reminder.Location, &reminder.TeamName, &reminder.RoleName); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		created, err := sendReminder(reminder)
+		if err != nil {
+			return sent, err
+		}
+		if created {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// sendReminder registra o lembrete e cria a notificação na mesma transação; devolve false se outra
+// instância já o enviou
+func sendReminder(reminder dueReminder) (bool, error) {
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.Background())
+
+	var id int
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO schedule_reminders (schedule_id, lead_hours, event_date)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (schedule_id, lead_hours, event_date) DO NOTHING
+		 RETURNING id`, reminder.ScheduleID, reminder.LeadHours, reminder.EventDate).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Você está escalado(a) como %s (%s) em %s às %s",
+		reminder.RoleName, reminder.TeamName, reminder.EventDate.Format("02/01/2006"), reminder.EventDate.Format("15:04"))
+	if reminder.Location != "" {
+		message += ", em " + reminder.Location
+	}
+	if _, err := createNotification(tx, reminder.UserID, reminder.EventTitle, message, "reminder"); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(context.Background())
+}
+
+// GetTeamReminderSettings retorna as antecedências dos lembretes de escala de um time
+func GetTeamReminderSettings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var leadHours []int
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT reminder_lead_hours FROM teams WHERE id = $1", id).Scan(&leadHours)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Equipe não encontrada",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar lembretes da equipe",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    teamReminderSettings(id, leadHours),
+	})
+}
+
+// UpdateTeamReminderSettings define as antecedências (em horas) dos lembretes de escala de um time.
+// Uma lista vazia volta a usar o padrão REMINDER_LEAD_HOURS.
+func UpdateTeamReminderSettings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var req models.TeamReminderSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, id) {
+		return
+	}
+
+	// Ordenar e remover repetições; sem antecedências, o time volta ao padrão (NULL)
+	var leadHours []int
+	seen := map[int]bool{}
+	for _, hours := range req.LeadHours {
+		if hours <= 0 || hours > maxReminderLeadHours {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   fmt.Sprintf("As antecedências devem estar entre 1 e %d horas", maxReminderLeadHours),
+			})
+			return
+		}
+		if !seen[hours] {
+			seen[hours] = true
+			leadHours = append(leadHours, hours)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(leadHours)))
+
+	var stored []int
+	err = db.DB.QueryRow(context.Background(),
+		"UPDATE teams SET reminder_lead_hours = $1 WHERE id = $2 RETURNING reminder_lead_hours",
+		leadHours, id).Scan(&stored)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Equipe não encontrada",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar lembretes da equipe",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Lembretes da equipe atualizados com sucesso",
+		Data:    teamReminderSettings(id, stored),
+	})
+}
+
+// teamReminderSettings monta a resposta das antecedências, aplicando o padrão quando o time não tem as suas
+func teamReminderSettings(teamID int, leadHours []int) models.TeamReminderSettings {
+	settings := models.TeamReminderSettings{TeamID: teamID, LeadHours: leadHours}
+	if len(leadHours) == 0 {
+		settings.LeadHours = AppConfig.ReminderLeadHours
+		settings.UsesDefault = true
+	}
+	return settings
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// Pontos de cada fator na classificação de substitutos. Candidatos com conflito ou indisponíveis
+// continuam na lista, mas sempre depois dos elegíveis.
+const (
+	replacementConflictPoints    = -100
+	replacementUnavailablePoints = -100
+	replacementSameDayPoints     = -20
+	replacementTraineePoints     = -30
+	// Cada escala na janela de histórico
+	replacementFrequencyPoints = -10
+	// Escalado há menos de replacementRecentDays dias
+	replacementRecentPoints = -15
+	// Nenhuma escala na janela de histórico
+	replacementRestedPoints = 10
+	replacementRecentDays   = 7
+)
+
+// GetReplacementSuggestions classifica os voluntários do mesmo time e função que poderiam cobrir um
+// agendamento, considerando disponibilidade, conflitos no mesmo dia, frequência e recência das escalas
+// e a condição de trainee. Disponível ao próprio voluntário do agendamento e aos líderes do time.
+func GetReplacementSuggestions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	lookbackDays := defaultLookbackDays
+	if value := c.Query("lookbackDays"); value != "" {
+		lookbackDays, err = strconv.Atoi(value)
+		if err != nil || lookbackDays <= 0 {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "lookbackDays deve ser um número positivo",
+			})
+			return
+		}
+	}
+
+	suggestions := models.ReplacementSuggestions{ScheduleID: id, LookbackDays: lookbackDays}
+	var ownerUserID int
+	var window scheduleWindow
+	err = db.DB.QueryRow(context.Background(),
+		`SELECT v.user_id, v.team_id, v.role_id, r.name, e.id, e.title, e.event_date,
+		        e.event_date + make_interval(mins => e.duration_minutes)
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN roles r ON v.role_id = r.id
+		 JOIN events e ON s.event_id = e.id
+		 WHERE s.id = $1`, id).
+		Scan(&ownerUserID, &suggestions.TeamID, &suggestions.RoleID, &suggestions.RoleName,
+			&suggestions.EventID, &suggestions.EventTitle, &window.Start, &window.End)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Agendamento não encontrado",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar agendamento",
+		})
+		return
+	}
+	suggestions.EventDate = window.Start
+
+	// O voluntário do agendamento pode consultar; os demais precisam liderar o time
+	if userID, exists := c.Get("userID"); !exists || userID != ownerUserID {
+		if !requireTeamScope(c, suggestions.TeamID) {
+			return
+		}
+	}
+
+	candidates, err := loadReplacementCandidates(suggestions, ownerUserID, window, lookbackDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao classificar substitutos",
+		})
+		return
+	}
+	suggestions.Candidates = candidates
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    suggestions,
+	})
+}
+
+// replacementSchedule é um agendamento de um candidato próximo ao evento a ser coberto
+type replacementSchedule struct {
+	EventID    int
+	EventTitle string
+	Window     scheduleWindow
+}
+
+// loadReplacementCandidates carrega e pontua os candidatos, já ordenados
+func loadReplacementCandidates(suggestions models.ReplacementSuggestions, ownerUserID int, window scheduleWindow, lookbackDays int) ([]models.ReplacementCandidate, error) {
+	// Voluntários ativos do mesmo time e função, com as escalas na janela anterior ao evento
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT v.id, v.user_id, COALESCE(v.is_trainee, false), u.name, COUNT(e.id), MAX(e.event_date)
+		 FROM volunteers v
+		 JOIN users u ON v.user_id = u.id
+		 LEFT JOIN schedules s ON s.volunteer_id = v.id AND s.status != 'cancelled'
+		 LEFT JOIN events e ON s.event_id = e.id AND e.event_date >= $3 AND e.event_date < $4
+		 WHERE v.team_id = $1 AND v.role_id = $2 AND v.user_id != $5 AND u.active = true
+		 GROUP BY v.id, v.user_id, v.is_trainee, u.name
+		 ORDER BY v.id`,
+		suggestions.TeamID, suggestions.RoleID, window.Start.AddDate(0, 0, -lookbackDays), window.Start, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.ReplacementCandidate
+	var volunteerIDs, userIDs []int
+	for rows.Next() {
+		var candidate models.ReplacementCandidate
+		if err := rows.Scan(&candidate.VolunteerID, &candidate.UserID, &candidate.IsTrainee, &candidate.Name,
+			&candidate.RecentAssignments, &candidate.LastAssigned); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+		volunteerIDs = append(volunteerIDs, candidate.VolunteerID)
+		userIDs = append(userIDs, candidate.UserID)
+	}
+	rows.Close()
+	if len(candidates) == 0 {
+		return []models.ReplacementCandidate{}, nil
+	}
+
+	// Regras de disponibilidade dos candidatos
+	rules, err := queryAvailabilityRules(db.DB,
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules
+		 WHERE volunteer_id = ANY($1)`, volunteerIDs)
+	if err != nil {
+		return nil, err
+	}
+	rulesByVolunteer := map[int][]models.AvailabilityRule{}
+	for _, rule := range rules {
+		rulesByVolunteer[rule.VolunteerID] = append(rulesByVolunteer[rule.VolunteerID], rule)
+	}
+
+	// Agendamentos dos candidatos (em qualquer time) do dia anterior ao seguinte, para conflitos
+	// que atravessam a meia-noite
+	day := truncateToDay(window.Start)
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT v.user_id, e.id, e.title, e.event_date, e.event_date + make_interval(mins => e.duration_minutes)
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN events e ON s.event_id = e.id
+		 WHERE v.user_id = ANY($1) AND s.status != 'cancelled'
+		 AND e.event_date >= $2 AND e.event_date < $3
+		 ORDER BY e.event_date`, userIDs, day.AddDate(0, 0, -1), day.AddDate(0, 0, 2))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schedulesByUser := map[int][]replacementSchedule{}
+	for rows.Next() {
+		var userID int
+		var schedule replacementSchedule
+		if err := rows.Scan(&userID, &schedule.EventID, &schedule.EventTitle,
+			&schedule.Window.Start, &schedule.Window.End); err != nil {
+			return nil, err
+		}
+		schedulesByUser[userID] = append(schedulesByUser[userID], schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	buffer := schedulingBuffer()
+	for i := range candidates {
+		scoreReplacementCandidate(&candidates[i], suggestions.EventID, window, buffer, lookbackDays,
+			rulesByVolunteer[candidates[i].VolunteerID], schedulesByUser[candidates[i].UserID])
+	}
+
+	// Elegíveis primeiro; depois maior pontuação, menos escalas e escala mais antiga
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.RecentAssignments != b.RecentAssignments {
+			return a.RecentAssignments < b.RecentAssignments
+		}
+		if (a.LastAssigned == nil) != (b.LastAssigned == nil) {
+			return a.LastAssigned == nil
+		}
+		if a.LastAssigned != nil && !a.LastAssigned.Equal(*b.LastAssigned) {
+			return a.LastAssigned.Before(*b.LastAssigned)
+		}
+		return a.VolunteerID < b.VolunteerID
+	})
+
+	return candidates, nil
+}
+
+// scoreReplacementCandidate calcula a pontuação do candidato e registra o motivo de cada fator
+func scoreReplacementCandidate(candidate *models.ReplacementCandidate, eventID int, window scheduleWindow, buffer time.Duration, lookbackDays int, rules []models.AvailabilityRule, schedules []replacementSchedule) {
+	candidate.Eligible = true
+	candidate.Reasons = []models.ReplacementReason{}
+	addReason := func(factor string, points int, description string) {
+		candidate.Score += points
+		candidate.Reasons = append(candidate.Reasons, models.ReplacementReason{
+			Factor:      factor,
+			Points:      points,
+			Description: description,
+		})
+	}
+
+	for _, violation := range matchAvailabilityRules(rules, window.Start) {
+		candidate.Eligible = false
+		addReason("availability", replacementUnavailablePoints, "Indisponível: "+violation.Reason)
+	}
+
+	day := truncateToDay(window.Start)
+	for _, schedule := range schedules {
+		switch {
+		case schedule.EventID == eventID:
+			candidate.Eligible = false
+			addReason("conflict", replacementConflictPoints, "Já está escalado(a) neste evento")
+		case schedule.Window.overlaps(window, buffer):
+			candidate.Eligible = false
+			addReason("conflict", replacementConflictPoints,
+				fmt.Sprintf("Conflito de horário com \"%s\" às %s", schedule.EventTitle, schedule.Window.Start.Format("15:04")))
+		case truncateToDay(schedule.Window.Start).Equal(day):
+			addReason("same_day", replacementSameDayPoints,
+				fmt.Sprintf("Já escalado(a) no mesmo dia em \"%s\" às %s", schedule.EventTitle, schedule.Window.Start.Format("15:04")))
+		}
+	}
+
+	if candidate.RecentAssignments > 0 {
+		addReason("frequency", replacementFrequencyPoints*candidate.RecentAssignments,
+			fmt.Sprintf("%d escala(s) nos últimos %d dias", candidate.RecentAssignments, lookbackDays))
+	}
+
+	if candidate.LastAssigned == nil {
+		addReason("recency", replacementRestedPoints, fmt.Sprintf("Nenhuma escala nos últimos %d dias", lookbackDays))
+	} else {
+		days := int(window.Start.Sub(*candidate.LastAssigned).Hours() / 24)
+		if days < replacementRecentDays {
+			addReason("recency", replacementRecentPoints, fmt.Sprintf("Escalado(a) há %d dia(s)", days))
+		} else {
+			addReason("recency", 0, fmt.Sprintf("Última escala há %d dias", days))
+		}
+	}
+
+	if candidate.IsTrainee {
+		addReason("trainee", replacementTraineePoints, "Trainee: precisa de um voluntário experiente como parceiro")
+	}
+}
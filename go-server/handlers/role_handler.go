@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// GetRoles retorna todos os papéis
+func GetRoles(c *gin.Context) {
+	rows, err := db.DB.Query(context.Background(), "SELECT id, name, team_id, description FROM roles ORDER BY team_id, name")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar papéis",
+		})
+		return
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.TeamID, &role.Description); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar papéis",
+			})
+			return
+		}
+		roles = append(roles, role)
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// GetRole retorna um papel específico pelo ID
+func GetRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var role models.Role
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT id, name, team_id, description FROM roles WHERE id = $1", id).
+		Scan(&role.ID, &role.Name, &role.TeamID, &role.Description)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Papel não encontrado",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    role,
+	})
+}
+
+// GetRolesByTeam retorna todos os papéis de um time específico
+func GetRolesByTeam(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("teamId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID de time inválido",
+		})
+		return
+	}
+
+	// Verificar se o time existe
+	var teamExists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1)", teamID).Scan(&teamExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar time",
+		})
+		return
+	}
+
+	if !teamExists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Time não encontrado",
+		})
+		return
+	}
+
+	rows, err := db.DB.Query(context.Background(),
+		"SELECT id, name, team_id, description FROM roles WHERE team_id = $1 ORDER BY name", teamID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar papéis do time",
+		})
+		return
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.TeamID, &role.Description); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar papéis",
+			})
+			return
+		}
+		roles = append(roles, role)
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// CreateRole cria um novo papel
+func CreateRole(c *gin.Context) {
+	var roleRequest models.RoleRequest
+
+	if err := c.ShouldBindJSON(&roleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Verificar se o time existe
+	var teamExists bool
+	err := db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1)",
+		roleRequest.TeamID).Scan(&teamExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar time",
+		})
+		return
+	}
+
+	if !teamExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Time não encontrado",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, roleRequest.TeamID) {
+		return
+	}
+
+	// Verificar se já existe um papel com o mesmo nome no time
+	var nameExists bool
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE team_id = $1 AND LOWER(name) = LOWER($2))",
+		roleRequest.TeamID, roleRequest.Name).Scan(&nameExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar papel existente",
+		})
+		return
+	}
+
+	if nameExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Já existe um papel com este nome neste time",
+		})
+		return
+	}
+
+	// Criar papel
+	var role models.Role
+	err = db.DB.QueryRow(context.Background(),
+		"INSERT INTO roles (name, team_id, description) VALUES ($1, $2, $3) RETURNING id, name, team_id, description",
+		roleRequest.Name, roleRequest.TeamID, roleRequest.Description).
+		Scan(&role.ID, &role.Name, &role.TeamID, &role.Description)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar papel",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Papel criado com sucesso",
+		Data:    role,
+	})
+}
+
+// UpdateRole atualiza um papel existente
+func UpdateRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var roleRequest models.RoleRequest
+	if err := c.ShouldBindJSON(&roleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Verificar se o papel existe
+	var currentTeamID int
+	err = db.DB.QueryRow(context.Background(), "SELECT team_id FROM roles WHERE id = $1", id).Scan(&currentTeamID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Papel não encontrado",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, currentTeamID, roleRequest.TeamID) {
+		return
+	}
+
+	// Não permitir mover para outro time um papel em uso, pois os voluntários ficariam com papel de outro time
+	if roleRequest.TeamID != currentTeamID {
+		var teamExists bool
+		err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1)",
+			roleRequest.TeamID).Scan(&teamExists)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao verificar time",
+			})
+			return
+		}
+
+		if !teamExists {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Time não encontrado",
+			})
+			return
+		}
+
+		var inUse bool
+		err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM volunteers WHERE role_id = $1)", id).Scan(&inUse)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao verificar voluntários",
+			})
+			return
+		}
+
+		if inUse {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Não é possível mover para outro time um papel com voluntários associados",
+			})
+			return
+		}
+	}
+
+	// Verificar se já existe outro papel com o mesmo nome no time
+	var nameExists bool
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE team_id = $1 AND LOWER(name) = LOWER($2) AND id != $3)",
+		roleRequest.TeamID, roleRequest.Name, id).Scan(&nameExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar papel existente",
+		})
+		return
+	}
+
+	if nameExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Já existe um papel com este nome neste time",
+		})
+		return
+	}
+
+	// Atualizar papel
+	var role models.Role
+	err = db.DB.QueryRow(context.Background(),
+		"UPDATE roles SET name = $1, team_id = $2, description = $3 WHERE id = $4 RETURNING id, name, team_id, description",
+		roleRequest.Name, roleRequest.TeamID, roleRequest.Description, id).
+		Scan(&role.ID, &role.Name, &role.TeamID, &role.Description)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar papel",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Papel atualizado com sucesso",
+		Data:    role,
+	})
+}
+
+// DeleteRole remove um papel
+func DeleteRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	// Verificar se o papel existe
+	var exists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar papel",
+		})
+		return
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Papel não encontrado",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireRoleTeamScope(c, id) {
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Verificar dependências (volunteers e convites pendentes); o papel fica bloqueado até o fim da transação
+	var hasVolunteers, hasInvitations bool
+	err = tx.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM volunteers WHERE role_id = r.id),
+		        EXISTS(SELECT 1 FROM invitations WHERE role_id = r.id
+		               AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW())
+		 FROM roles r WHERE r.id = $1 FOR UPDATE`, id).Scan(&hasVolunteers, &hasInvitations)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Papel não encontrado",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar dependências do papel",
+		})
+		return
+	}
+
+	if hasVolunteers {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Não é possível excluir papel com voluntários associados",
+		})
+		return
+	}
+
+	if hasInvitations {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Não é possível excluir papel com convites pendentes",
+		})
+		return
+	}
+
+	// Excluir convites já aceitos, revogados ou expirados
+	_, err = tx.Exec(context.Background(), "DELETE FROM invitations WHERE role_id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir convites do papel",
+		})
+		return
+	}
+
+	// Excluir necessidades de escala associadas
+	_, err = tx.Exec(context.Background(), "DELETE FROM staffing_requirements WHERE role_id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir necessidades de escala",
+		})
+		return
+	}
+
+	// Excluir papel
+	_, err = tx.Exec(context.Background(), "DELETE FROM roles WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir papel",
+		})
+		return
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Papel excluído com sucesso",
+	})
+}
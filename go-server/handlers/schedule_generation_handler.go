@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+const (
+	// defaultLookbackDays é a janela usada para medir quantas vezes cada voluntário foi escalado recentemente
+	defaultLookbackDays = 90
+	// maxGenerationDays limita o período que pode ser gerado de uma só vez
+	maxGenerationDays = 93
+)
+
+// GenerateScheduleDraft gera um rascunho de escala para os eventos de um período.
+// O rascunho não é gravado: o líder revisa e envia o resultado para CommitScheduleDraft.
+func GenerateScheduleDraft(c *gin.Context) {
+	var generationRequest models.ScheduleGenerationRequest
+
+	if err := c.ShouldBindJSON(&generationRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	startDay := truncateToDay(generationRequest.StartDate)
+	endDay := truncateToDay(generationRequest.EndDate)
+	if endDay.Before(startDay) {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "A data final deve ser igual ou posterior à data inicial",
+		})
+		return
+	}
+
+	if endDay.Sub(startDay) > maxGenerationDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "O período máximo para geração de escala é de 93 dias",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, generationRequest.TeamIDs...) {
+		return
+	}
+
+	if generationRequest.LookbackDays <= 0 {
+		generationRequest.LookbackDays = defaultLookbackDays
+	}
+
+	generator, err := loadScheduleGenerator(generationRequest.TeamIDs, startDay, endDay.AddDate(0, 0, 1), generationRequest.LookbackDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao carregar dados para geração da escala",
+		})
+		return
+	}
+
+	draft, err := generator.generate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao gerar escala",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Rascunho de escala gerado com sucesso",
+		Data:    draft,
+	})
+}
+
+// CommitScheduleDraft grava em uma única transação os agendamentos de um rascunho revisado.
+// Todos os agendamentos são validados novamente dentro da transação; se algum for inválido, nada é gravado.
+func CommitScheduleDraft(c *gin.Context) {
+	var commitRequest models.ScheduleDraftCommitRequest
+
+	if err := c.ShouldBindJSON(&commitRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Líderes só podem gravar agendamentos de voluntários dos times que lideram
+	volunteerIDs := make([]int, 0, len(commitRequest.Assignments))
+	for _, assignment := range commitRequest.Assignments {
+		volunteerIDs = append(volunteerIDs, assignment.VolunteerID)
+	}
+	if !requireVolunteerTeamScope(c, volunteerIDs...) {
+		return
+	}
+
+	// O autor da escala é o usuário logado; o createdById do corpo só vale sem autenticação (AUTH_MODE=off)
+	createdByID := commitRequest.CreatedByID
+	if userID, exists := c.Get("userID"); exists {
+		if id, ok := userID.(int); ok {
+			createdByID = id
+		}
+	}
+	if createdByID == 0 {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Gravações concorrentes de agendamentos dos mesmos usuários esperam esta transação, para que a
+	// validação abaixo continue valendo até a confirmação
+	if err := lockScheduleUsers(tx, volunteerIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao validar rascunho de escala",
+		})
+		return
+	}
+
+	issues, err := validateDraftAssignments(tx, commitRequest.Assignments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao validar rascunho de escala",
+		})
+		return
+	}
+
+	if len(issues) > 0 {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "O rascunho contém agendamentos inválidos",
+			Data:    issues,
+		})
+		return
+	}
+
+	var schedules []models.Schedule
+	for _, assignment := range commitRequest.Assignments {
+		var schedule models.Schedule
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO schedules (event_id, volunteer_id, status, trainee_partner_id, created_by_id)
+			 VALUES ($1, $2, 'confirmed', $3, $4)
+			 RETURNING id, event_id, volunteer_id, status, trainee_partner_id, created_by_id, created_at`,
+			assignment.EventID, assignment.VolunteerID, assignment.TraineePartnerID, createdByID).
+			Scan(&schedule.ID, &schedule.EventID, &schedule.VolunteerID, &schedule.Status,
+				&schedule.TraineePartnerID, &schedule.CreatedByID, &schedule.CreatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao criar agendamento",
+			})
+			return
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Escala gravada com sucesso",
+		Data:    schedules,
+	})
+}
+
+// generatorEvent é um evento do período sendo escalado
+type generatorEvent struct {
+	ID        int
+	Title     string
+	EventDate time.Time
+	EndDate   time.Time
+}
+
+// generatorRole é uma vaga (papel de um time) a ser preenchida em cada evento
+type generatorRole struct {
+	ID     int
+	TeamID int
+	Name   string
+}
+
+// generatorVolunteer é um candidato a vagas, com seu histórico recente de escalas
+type generatorVolunteer struct {
+	ID           int
+	UserID       int
+	TeamID       int
+	RoleID       int
+	IsTrainee    bool
+	Name         string
+	RecentCount  int
+	LastAssigned time.Time
+	Rules        []models.AvailabilityRule
+}
+
+// scheduleGenerator mantém o estado de uma geração de escala
+type scheduleGenerator struct {
+	events     []generatorEvent
+	roles      []generatorRole
+	volunteers []*generatorVolunteer
+
+	// requirements guarda a necessidade de cada papel por evento (papéis sem necessidade não são escalados)
+	requirements map[int]map[int]int
+	// filled conta as vagas (evento, papel) já ocupadas por voluntários não-trainee
+	filled map[[2]int]int
+	// inEvent indica voluntários já presentes em cada evento
+	inEvent map[int]map[int]bool
+	// draftWindows guarda os horários em que cada usuário já foi escalado neste rascunho
+	draftWindows map[int][]scheduleWindow
+	// buffer é o intervalo mínimo entre dois eventos do mesmo usuário
+	buffer time.Duration
+}
+
+// loadScheduleGenerator carrega eventos, papéis, voluntários, regras e histórico dos times informados
+func loadScheduleGenerator(teamIDs []int, from, to time.Time, lookbackDays int) (*scheduleGenerator, error) {
+	g := &scheduleGenerator{
+		filled:       map[[2]int]int{},
+		inEvent:      map[int]map[int]bool{},
+		draftWindows: map[int][]scheduleWindow{},
+		buffer:       schedulingBuffer(),
+	}
+
+	// Eventos do período
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT id, title, event_date, event_date + make_interval(mins => duration_minutes) FROM events
+		 WHERE event_date >= $1 AND event_date < $2
+		 ORDER BY event_date, id`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var eventIDs []int
+	for rows.Next() {
+		var event generatorEvent
+		if err := rows.Scan(&event.ID, &event.Title, &event.EventDate, &event.EndDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		g.events = append(g.events, event)
+		eventIDs = append(eventIDs, event.ID)
+	}
+	rows.Close()
+
+	// Papéis dos times
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT id, team_id, name FROM roles WHERE team_id = ANY($1) ORDER BY team_id, name`, teamIDs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var role generatorRole
+		if err := rows.Scan(&role.ID, &role.TeamID, &role.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		g.roles = append(g.roles, role)
+	}
+	rows.Close()
+
+	// Voluntários ativos dos times, com o número de escalas na janela anterior ao período
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT v.id, v.user_id, v.team_id, v.role_id, COALESCE(v.is_trainee, false), u.name,
+		        COUNT(e.id), COALESCE(MAX(e.event_date), '1970-01-01'::timestamp)
+		 FROM volunteers v
+		 JOIN users u ON v.user_id = u.id
+		 LEFT JOIN schedules s ON s.volunteer_id = v.id AND s.status != 'cancelled'
+		 LEFT JOIN events e ON s.event_id = e.id AND e.event_date >= $2 AND e.event_date < $3
+		 WHERE v.team_id = ANY($1) AND u.active = true
+		 GROUP BY v.id, v.user_id, v.team_id, v.role_id, v.is_trainee, u.name
+		 ORDER BY v.id`, teamIDs, from.AddDate(0, 0, -lookbackDays), from)
+	if err != nil {
+		return nil, err
+	}
+	volunteersByID := map[int]*generatorVolunteer{}
+	var volunteerIDs []int
+	for rows.Next() {
+		volunteer := &generatorVolunteer{}
+		if err := rows.Scan(&volunteer.ID, &volunteer.UserID, &volunteer.TeamID, &volunteer.RoleID,
+			&volunteer.IsTrainee, &volunteer.Name, &volunteer.RecentCount, &volunteer.LastAssigned); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		g.volunteers = append(g.volunteers, volunteer)
+		volunteersByID[volunteer.ID] = volunteer
+		volunteerIDs = append(volunteerIDs, volunteer.ID)
+	}
+	rows.Close()
+
+	// Regras de disponibilidade dos voluntários
+	rules, err := queryAvailabilityRules(db.DB,
+		`SELECT id, volunteer_id, description, day_of_week, start_time, end_time, start_date, end_date
+		 FROM availability_rules
+		 WHERE volunteer_id = ANY($1)`, volunteerIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if volunteer, ok := volunteersByID[rule.VolunteerID]; ok {
+			volunteer.Rules = append(volunteer.Rules, rule)
+		}
+	}
+
+	// Necessidades de escala dos eventos do período
+	g.requirements, err = loadStaffingRequirements(eventIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Agendamentos já existentes nos eventos do período
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT s.event_id, s.volunteer_id, v.role_id, COALESCE(v.is_trainee, false)
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 WHERE s.event_id = ANY($1) AND s.status != 'cancelled'`, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID, volunteerID, roleID int
+		var isTrainee bool
+		if err := rows.Scan(&eventID, &volunteerID, &roleID, &isTrainee); err != nil {
+			return nil, err
+		}
+		if !isTrainee {
+			g.filled[[2]int{eventID, roleID}]++
+		}
+		g.markInEvent(eventID, volunteerID)
+	}
+
+	return g, rows.Err()
+}
+
+// generate preenche as vagas de cada (evento, papel) com os candidatos elegíveis menos escalados.
+// Papéis sem necessidade de escala cadastrada são ignorados, como na cobertura.
+func (g *scheduleGenerator) generate() (models.ScheduleDraft, error) {
+	draft := models.ScheduleDraft{
+		Assignments: []models.GeneratedAssignment{},
+		Unfilled:    []models.UnfilledSlot{},
+	}
+
+	for _, event := range g.events {
+		for _, role := range g.roles {
+			required, ok := requiredStaffing(g.requirements, event.ID, role.ID)
+			if !ok {
+				continue
+			}
+
+			key := [2]int{event.ID, role.ID}
+			for g.filled[key] < required {
+				primary, err := g.pickCandidate(event, role, false)
+				if err != nil {
+					return draft, err
+				}
+				if primary == nil {
+					draft.Unfilled = append(draft.Unfilled, models.UnfilledSlot{
+						EventID:    event.ID,
+						EventTitle: event.Title,
+						EventDate:  event.EventDate,
+						TeamID:     role.TeamID,
+						RoleID:     role.ID,
+						RoleName:   role.Name,
+						Missing:    required - g.filled[key],
+						Reason:     "Nenhum voluntário disponível para esta vaga",
+					})
+					break
+				}
+				draft.Assignments = append(draft.Assignments, g.assign(event, role, primary, nil))
+				g.filled[key]++
+
+				// Trainees acompanham um voluntário experiente do mesmo papel
+				trainee, err := g.pickCandidate(event, role, true)
+				if err != nil {
+					return draft, err
+				}
+				if trainee != nil {
+					partnerID := primary.ID
+					draft.Assignments = append(draft.Assignments, g.assign(event, role, trainee, &partnerID))
+				}
+			}
+		}
+	}
+
+	return draft, nil
+}
+
+// pickCandidate escolhe, entre os voluntários do papel, o mais justo que esteja disponível
+func (g *scheduleGenerator) pickCandidate(event generatorEvent, role generatorRole, trainee bool) (*generatorVolunteer, error) {
+	var candidates []*generatorVolunteer
+	for _, volunteer := range g.volunteers {
+		if volunteer.RoleID == role.ID && volunteer.IsTrainee == trainee && !g.inEvent[event.ID][volunteer.ID] {
+			candidates = append(candidates, volunteer)
+		}
+	}
+
+	// Menos escalas recentes primeiro; em caso de empate, quem foi escalado há mais tempo
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].RecentCount != candidates[j].RecentCount {
+			return candidates[i].RecentCount < candidates[j].RecentCount
+		}
+		if !candidates[i].LastAssigned.Equal(candidates[j].LastAssigned) {
+			return candidates[i].LastAssigned.Before(candidates[j].LastAssigned)
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	window := scheduleWindow{Start: event.EventDate, End: event.EndDate}
+	for _, candidate := range candidates {
+		if overlapsAny(g.draftWindows[candidate.UserID], window, g.buffer) {
+			continue
+		}
+		if len(matchAvailabilityRules(candidate.Rules, event.EventDate)) > 0 {
+			continue
+		}
+		hasConflict, err := checkSchedulingConflict(db.DB, event.ID, candidate.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+		if hasConflict {
+			continue
+		}
+		return candidate, nil
+	}
+
+	return nil, nil
+}
+
+// assign registra o agendamento no estado do gerador e devolve a proposta correspondente
+func (g *scheduleGenerator) assign(event generatorEvent, role generatorRole, volunteer *generatorVolunteer, partnerID *int) models.GeneratedAssignment {
+	assignment := models.GeneratedAssignment{
+		EventID:           event.ID,
+		EventTitle:        event.Title,
+		EventDate:         event.EventDate,
+		TeamID:            role.TeamID,
+		RoleID:            role.ID,
+		RoleName:          role.Name,
+		VolunteerID:       volunteer.ID,
+		VolunteerName:     volunteer.Name,
+		IsTrainee:         volunteer.IsTrainee,
+		TraineePartnerID:  partnerID,
+		RecentAssignments: volunteer.RecentCount,
+	}
+
+	g.markInEvent(event.ID, volunteer.ID)
+	g.draftWindows[volunteer.UserID] = append(g.draftWindows[volunteer.UserID],
+		scheduleWindow{Start: event.EventDate, End: event.EndDate})
+	volunteer.RecentCount++
+	volunteer.LastAssigned = event.EventDate
+
+	return assignment
+}
+
+// markInEvent registra que o voluntário já participa do evento
+func (g *scheduleGenerator) markInEvent(eventID, volunteerID int) {
+	if g.inEvent[eventID] == nil {
+		g.inEvent[eventID] = map[int]bool{}
+	}
+	g.inEvent[eventID][volunteerID] = true
+}
+
+// validateDraftAssignments revalida os agendamentos de um rascunho antes de gravá-los
+func validateDraftAssignments(q rowQuerier, assignments []models.DraftAssignment) ([]models.DraftIssue, error) {
+	var issues []models.DraftIssue
+	seen := map[[2]int]bool{}
+	// horários já ocupados por cada usuário dentro do próprio rascunho
+	draftWindows := map[int][]scheduleWindow{}
+	buffer := schedulingBuffer()
+	// pares (evento, voluntário) do rascunho, para aceitar parceiros de trainees ainda não gravados
+	planned := map[[2]int]bool{}
+	for _, assignment := range assignments {
+		planned[[2]int{assignment.EventID, assignment.VolunteerID}] = true
+	}
+
+	for i, assignment := range assignments {
+		issue := models.DraftIssue{Index: i, EventID: assignment.EventID, VolunteerID: assignment.VolunteerID}
+
+		key := [2]int{assignment.EventID, assignment.VolunteerID}
+		if seen[key] {
+			issue.Reason = "Agendamento duplicado no rascunho"
+			issues = append(issues, issue)
+			continue
+		}
+		seen[key] = true
+
+		var window scheduleWindow
+		err := q.QueryRow(context.Background(),
+			"SELECT event_date, event_date + make_interval(mins => duration_minutes) FROM events WHERE id = $1",
+			assignment.EventID).Scan(&window.Start, &window.End)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, err
+		}
+		if err == pgx.ErrNoRows {
+			issue.Reason = "Evento não encontrado"
+			issues = append(issues, issue)
+			continue
+		}
+
+		var userID int
+		err = q.QueryRow(context.Background(), "SELECT user_id FROM volunteers WHERE id = $1",
+			assignment.VolunteerID).Scan(&userID)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, err
+		}
+		if err == pgx.ErrNoRows {
+			issue.Reason = "Voluntário não encontrado"
+			issues = append(issues, issue)
+			continue
+		}
+
+		var scheduleExists bool
+		err = q.QueryRow(context.Background(),
+			"SELECT EXISTS(SELECT 1 FROM schedules WHERE event_id = $1 AND volunteer_id = $2)",
+			assignment.EventID, assignment.VolunteerID).Scan(&scheduleExists)
+		if err != nil {
+			return nil, err
+		}
+		if scheduleExists {
+			issue.Reason = "Este voluntário já está agendado para este evento"
+			issues = append(issues, issue)
+			continue
+		}
+
+		hasConflict, err := checkSchedulingConflict(q, assignment.EventID, assignment.VolunteerID, 0)
+		if err != nil {
+			return nil, err
+		}
+		if hasConflict || overlapsAny(draftWindows[userID], window, buffer) {
+			issue.Reason = "Conflito de horário: o voluntário já está agendado para outro evento no mesmo horário"
+			issues = append(issues, issue)
+			continue
+		}
+		draftWindows[userID] = append(draftWindows[userID], window)
+
+		violations, err := checkAvailabilityViolations(q, assignment.EventID, assignment.VolunteerID)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			issue.Reason = "O voluntário está indisponível para este evento: " + violations[0].Reason
+			issues = append(issues, issue)
+			continue
+		}
+
+		pairingIssue, err := checkTraineePairing(q, assignment.EventID, assignment.VolunteerID, assignment.TraineePartnerID, planned)
+		if err != nil {
+			return nil, err
+		}
+		if pairingIssue != "" {
+			issue.Reason = pairingIssue
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// overlapsAny indica se o intervalo se sobrepõe a algum dos intervalos já ocupados
+func overlapsAny(windows []scheduleWindow, window scheduleWindow, buffer time.Duration) bool {
+	for _, other := range windows {
+		if other.overlaps(window, buffer) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateToDay descarta o horário, mantendo apenas a data
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
 	"volunteer-scheduler/db"
 	"volunteer-scheduler/models"
 )
@@ -97,93 +98,60 @@ func CreateSchedule(c *gin.Context) {
 		return
 	}
 
-	// Verificar se o evento existe
-	var eventExists bool
-	err := db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)", 
-		scheduleRequest.EventID).Scan(&eventExists)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao verificar evento",
-		})
+	// Verificar se o evento e o voluntário existem
+	if !requireScheduleReferences(c, scheduleRequest) {
 		return
 	}
 
-	if !eventExists {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Evento não encontrado",
-		})
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireVolunteerTeamScope(c, scheduleRequest.VolunteerID) {
 		return
 	}
 
-	// Verificar se o voluntário existe
-	var volunteerExists bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM volunteers WHERE id = $1)", 
-		scheduleRequest.VolunteerID).Scan(&volunteerExists)
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
-			Error:   "Erro ao verificar voluntário",
-		})
-		return
-	}
-
-	if !volunteerExists {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Voluntário não encontrado",
+			Error:   "Erro ao iniciar transação",
 		})
 		return
 	}
+	defer tx.Rollback(context.Background())
 
-	// Verificar se o agendamento já existe para esse evento e voluntário
-	var scheduleExists bool
-	err = db.DB.QueryRow(context.Background(), 
-		"SELECT EXISTS(SELECT 1 FROM schedules WHERE event_id = $1 AND volunteer_id = $2)", 
-		scheduleRequest.EventID, scheduleRequest.VolunteerID).Scan(&scheduleExists)
-	if err != nil {
+	// As verificações abaixo valem até a confirmação: outras gravações para os mesmos usuários esperam
+	if err := lockScheduleUsers(tx, scheduleRequestVolunteers(scheduleRequest)); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
-			Error:   "Erro ao verificar agendamento existente",
+			Error:   "Erro ao verificar conflitos de horário",
 		})
 		return
 	}
 
-	if scheduleExists {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Este voluntário já está agendado para este evento",
-		})
+	// Verificar agendamento duplicado e conflitos de horário
+	if !requireNoScheduleConflict(c, tx, scheduleRequest, 0) {
 		return
 	}
 
-	// Verificar conflitos de horário
-	hasConflict, err := checkSchedulingConflict(scheduleRequest.EventID, scheduleRequest.VolunteerID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao verificar conflitos de horário",
-		})
-		return
+	// Definir status padrão se não fornecido
+	if scheduleRequest.Status == "" {
+		scheduleRequest.Status = "confirmed"
 	}
 
-	if hasConflict {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Conflito de horário: o voluntário já está agendado para outro evento no mesmo horário",
-		})
+	// Verificar regras de disponibilidade do voluntário
+	violations, ok := enforceAvailability(c, tx, scheduleRequest)
+	if !ok {
 		return
 	}
 
-	// Definir status padrão se não fornecido
-	if scheduleRequest.Status == "" {
-		scheduleRequest.Status = "confirmed"
+	// Trainees só servem acompanhados de um parceiro experiente
+	if !enforceTraineePairing(c, tx, scheduleRequest) {
+		return
 	}
 
 	// Criar agendamento
 	var schedule models.Schedule
-	err = db.DB.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO schedules (event_id, volunteer_id, status, trainee_partner_id, created_by_id) 
 		 VALUES ($1, $2, $3, $4, $5) 
 		 RETURNING id, event_id, volunteer_id, status, trainee_partner_id, created_by_id, created_at`,
@@ -200,11 +168,26 @@ func CreateSchedule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.ApiResponse{
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	response := models.ApiResponse{
 		Success: true,
 		Message: "Agendamento criado com sucesso",
 		Data:    schedule,
-	})
+	}
+	if len(violations) > 0 {
+		response.Message = "Agendamento criado com avisos de disponibilidade"
+		response.Warnings = violations
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateSchedule atualiza um agendamento existente
@@ -228,8 +211,16 @@ func UpdateSchedule(c *gin.Context) {
 	}
 
 	// Verificar se o agendamento existe
-	var exists bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM schedules WHERE id = $1)", id).Scan(&exists)
+	var currentEventID, currentVolunteerID int
+	err = db.DB.QueryRow(context.Background(), "SELECT event_id, volunteer_id FROM schedules WHERE id = $1", id).
+		Scan(&currentEventID, &currentVolunteerID)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Agendamento não encontrado",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -238,11 +229,13 @@ func UpdateSchedule(c *gin.Context) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
-			Success: false,
-			Error:   "Agendamento não encontrado",
-		})
+	// Verificar se o evento e o voluntário existem
+	if !requireScheduleReferences(c, scheduleRequest) {
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !(requireScheduleTeamScope(c, id) && requireVolunteerTeamScope(c, scheduleRequest.VolunteerID)) {
 		return
 	}
 
@@ -251,9 +244,53 @@ func UpdateSchedule(c *gin.Context) {
 		scheduleRequest.Status = "confirmed"
 	}
 
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// As verificações abaixo valem até a confirmação: outras gravações para os mesmos usuários esperam
+	if err := lockScheduleUsers(tx, scheduleRequestVolunteers(scheduleRequest, currentVolunteerID)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar conflitos de horário",
+		})
+		return
+	}
+
+	// Verificar agendamento duplicado e conflitos de horário (ignorando o próprio agendamento)
+	if scheduleRequest.Status != "cancelled" && !requireNoScheduleConflict(c, tx, scheduleRequest, id) {
+		return
+	}
+
+	// Verificar regras de disponibilidade do voluntário
+	violations, ok := enforceAvailability(c, tx, scheduleRequest)
+	if !ok {
+		return
+	}
+
+	// Trainees só servem acompanhados de um parceiro experiente
+	if !enforceTraineePairing(c, tx, scheduleRequest) {
+		return
+	}
+
+	// O voluntário não pode sair do evento deixando sozinhos os trainees que acompanha
+	if scheduleRequest.Status == "cancelled" || scheduleRequest.EventID != currentEventID ||
+		scheduleRequest.VolunteerID != currentVolunteerID {
+		if !requireNoDependentTrainees(c, tx, currentEventID, currentVolunteerID, id) {
+			return
+		}
+	}
+
 	// Atualizar agendamento
 	var schedule models.Schedule
-	err = db.DB.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`UPDATE schedules 
 		 SET event_id = $1, volunteer_id = $2, status = $3, trainee_partner_id = $4, created_by_id = $5 
 		 WHERE id = $6 
@@ -271,11 +308,26 @@ func UpdateSchedule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ApiResponse{
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	response := models.ApiResponse{
 		Success: true,
 		Message: "Agendamento atualizado com sucesso",
 		Data:    schedule,
-	})
+	}
+	if len(violations) > 0 {
+		response.Message = "Agendamento atualizado com avisos de disponibilidade"
+		response.Warnings = violations
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteSchedule remove um agendamento
@@ -290,8 +342,16 @@ func DeleteSchedule(c *gin.Context) {
 	}
 
 	// Verificar se o agendamento existe
-	var exists bool
-	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM schedules WHERE id = $1)", id).Scan(&exists)
+	var eventID, volunteerID int
+	err = db.DB.QueryRow(context.Background(), "SELECT event_id, volunteer_id FROM schedules WHERE id = $1", id).
+		Scan(&eventID, &volunteerID)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Agendamento não encontrado",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Success: false,
@@ -300,11 +360,13 @@ func DeleteSchedule(c *gin.Context) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
-			Success: false,
-			Error:   "Agendamento não encontrado",
-		})
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireScheduleTeamScope(c, id) {
+		return
+	}
+
+	// Não deixar sozinhos os trainees acompanhados por este voluntário
+	if !requireNoDependentTrainees(c, db.DB, eventID, volunteerID, id) {
 		return
 	}
 
@@ -520,29 +582,231 @@ func GetSchedulesByVolunteer(c *gin.Context) {
 	})
 }
 
-// checkSchedulingConflict verifica se há conflito de horário para um voluntário em um evento
-func checkSchedulingConflict(eventID, volunteerID int) (bool, error) {
-	// Obter data e hora do evento
-	var eventDate time.Time
-	err := db.DB.QueryRow(context.Background(), 
-		"SELECT event_date FROM events WHERE id = $1", eventID).Scan(&eventDate)
+// checkSchedulingConflict verifica se há conflito de horário para um voluntário em um evento.
+// Dois eventos conflitam quando seus intervalos (início até início + duração), acrescidos do
+// intervalo mínimo de deslocamento, se sobrepõem. A verificação considera todos os times do usuário
+// e ignora agendamentos cancelados e o agendamento ignoredScheduleID (0 para nenhum), que está sendo alterado.
+func checkSchedulingConflict(q rowQuerier, eventID, volunteerID, ignoredScheduleID int) (bool, error) {
+	// Obter início e término do evento
+	var window scheduleWindow
+	err := q.QueryRow(context.Background(), 
+		"SELECT event_date, event_date + make_interval(mins => duration_minutes) FROM events WHERE id = $1", eventID).
+		Scan(&window.Start, &window.End)
 	if err != nil {
 		return false, err
 	}
 
-	// Buscar outros eventos no mesmo dia para os quais o voluntário já está agendado
-	rows, err := db.DB.Query(context.Background(), 
-		`SELECT e.id, e.event_date
-		 FROM schedules s
-		 JOIN events e ON s.event_id = e.id
-		 WHERE s.volunteer_id = $1 AND e.id != $2
-		 AND DATE(e.event_date) = DATE($3)`, volunteerID, eventID, eventDate)
+	bufferMinutes := int(schedulingBuffer() / time.Minute)
+
+	var hasConflict bool
+	err = q.QueryRow(context.Background(), 
+		`SELECT EXISTS(
+			SELECT 1
+			FROM schedules s
+			JOIN volunteers v ON s.volunteer_id = v.id
+			JOIN events e ON s.event_id = e.id
+			WHERE v.user_id = (SELECT user_id FROM volunteers WHERE id = $1)
+			AND e.id != $2 AND s.id != $6 AND s.status != 'cancelled'
+			AND e.event_date < $4::timestamp + make_interval(mins => $5)
+			AND e.event_date + make_interval(mins => e.duration_minutes + $5) > $3)`,
+		volunteerID, eventID, window.Start, window.End, bufferMinutes, ignoredScheduleID).Scan(&hasConflict)
 	if err != nil {
 		return false, err
 	}
-	defer rows.Close()
 
-	// Se encontrarmos qualquer evento no mesmo dia, consideramos como conflito
-	// Em uma implementação mais avançada, poderíamos comparar horários específicos
-	return rows.Next(), nil
-}
\ No newline at end of file
+	return hasConflict, nil
+}
+
+// requireScheduleReferences verifica se o evento e o voluntário do agendamento existem.
+// Retorna false quando a resposta já foi escrita.
+func requireScheduleReferences(c *gin.Context, scheduleRequest models.ScheduleRequest) bool {
+	var eventExists, volunteerExists bool
+	err := db.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM events WHERE id = $1), EXISTS(SELECT 1 FROM volunteers WHERE id = $2)`,
+		scheduleRequest.EventID, scheduleRequest.VolunteerID).Scan(&eventExists, &volunteerExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar evento e voluntário",
+		})
+		return false
+	}
+
+	if !eventExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Evento não encontrado",
+		})
+		return false
+	}
+	if !volunteerExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Voluntário não encontrado",
+		})
+		return false
+	}
+	return true
+}
+
+// scheduleUsersLockSpace identifica os advisory locks, por usuário, das gravações de agendamentos
+const scheduleUsersLockSpace = 1005
+
+// lockScheduleUsers bloqueia, até o fim da transação, os usuários dos voluntários informados (em ordem, para
+// evitar deadlocks entre transações que os disputam). Toda gravação que cria ou move agendamentos (manual,
+// rascunho, troca ou turno em aberto) chama esta função antes de verificar conflitos, para que duas
+// gravações simultâneas não escalem o mesmo usuário em horários sobrepostos.
+func lockScheduleUsers(tx pgx.Tx, volunteerIDs []int) error {
+	_, err := tx.Exec(context.Background(),
+		`SELECT pg_advisory_xact_lock($1, user_id)
+		 FROM (SELECT DISTINCT user_id FROM volunteers WHERE id = ANY($2) ORDER BY user_id) users`,
+		scheduleUsersLockSpace, volunteerIDs)
+	return err
+}
+
+// scheduleRequestVolunteers lista os voluntários cujos agendamentos a requisição afeta: o voluntário
+// escalado, o parceiro de trainee e os informados em others (por exemplo, o voluntário anterior)
+func scheduleRequestVolunteers(scheduleRequest models.ScheduleRequest, others ...int) []int {
+	volunteerIDs := append([]int{scheduleRequest.VolunteerID}, others...)
+	if scheduleRequest.TraineePartnerID != nil {
+		volunteerIDs = append(volunteerIDs, *scheduleRequest.TraineePartnerID)
+	}
+	return volunteerIDs
+}
+
+// requireNoScheduleConflict recusa um agendamento duplicado no mesmo evento ou com conflito de horário,
+// ignorando o agendamento scheduleID (0 na criação). Retorna false quando a resposta já foi escrita.
+func requireNoScheduleConflict(c *gin.Context, q rowQuerier, scheduleRequest models.ScheduleRequest, scheduleID int) bool {
+	// Verificar se o agendamento já existe para esse evento e voluntário
+	var scheduleExists bool
+	err := q.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM schedules WHERE event_id = $1 AND volunteer_id = $2 AND id != $3)",
+		scheduleRequest.EventID, scheduleRequest.VolunteerID, scheduleID).Scan(&scheduleExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar agendamento existente",
+		})
+		return false
+	}
+
+	if scheduleExists {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Este voluntário já está agendado para este evento",
+		})
+		return false
+	}
+
+	// Verificar conflitos de horário
+	hasConflict, err := checkSchedulingConflict(q, scheduleRequest.EventID, scheduleRequest.VolunteerID, scheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar conflitos de horário",
+		})
+		return false
+	}
+
+	if hasConflict {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Conflito de horário: o voluntário já está agendado para outro evento no mesmo horário",
+		})
+		return false
+	}
+	return true
+}
+
+// scheduleWindow é o intervalo de tempo ocupado por um evento
+type scheduleWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// overlaps indica se dois intervalos se sobrepõem, exigindo buffer de folga entre eles
+func (w scheduleWindow) overlaps(other scheduleWindow, buffer time.Duration) bool {
+	return w.Start.Before(other.End.Add(buffer)) && other.Start.Before(w.End.Add(buffer))
+}
+
+// schedulingBuffer retorna o intervalo mínimo entre dois eventos do mesmo voluntário (deslocamento/preparação)
+func schedulingBuffer() time.Duration {
+	return time.Duration(AppConfig.ScheduleBufferMinutes) * time.Minute
+}
+
+// enforceAvailability verifica as regras de disponibilidade para um agendamento.
+// Sem ignoreAvailability, qualquer violação rejeita a requisição com a lista de regras
+// violadas; com ele, as violações são devolvidas para serem enviadas como avisos.
+// Retorna false quando a resposta já foi escrita.
+func enforceAvailability(c *gin.Context, q rowQuerier, scheduleRequest models.ScheduleRequest) ([]models.AvailabilityViolation, bool) {
+	// Agendamentos cancelados não ocupam o voluntário
+	if scheduleRequest.Status == "cancelled" {
+		return nil, true
+	}
+
+	violations, err := checkAvailabilityViolations(q, scheduleRequest.EventID, scheduleRequest.VolunteerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar regras de disponibilidade",
+		})
+		return nil, false
+	}
+
+	if len(violations) > 0 && !scheduleRequest.IgnoreAvailability {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "O voluntário está indisponível para este evento",
+			Data:    violations,
+		})
+		return nil, false
+	}
+
+	return violations, true
+}
+
+// enforceTraineePairing aplica checkTraineePairing a um agendamento. Agendamentos cancelados não são verificados.
+// Retorna false quando a resposta já foi escrita.
+func enforceTraineePairing(c *gin.Context, q rowQuerier, scheduleRequest models.ScheduleRequest) bool {
+	if scheduleRequest.Status == "cancelled" {
+		return true
+	}
+
+	issue, err := checkTraineePairing(q, scheduleRequest.EventID, scheduleRequest.VolunteerID, scheduleRequest.TraineePartnerID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar parceiro do trainee",
+		})
+		return false
+	}
+	if issue != "" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   issue,
+		})
+		return false
+	}
+	return true
+}
+
+// requireNoDependentTrainees recusa a saída de um voluntário de um evento em que ele acompanha trainees.
+// A verificação usa q, a transação que grava a saída quando houver. Retorna false quando a resposta já foi escrita.
+func requireNoDependentTrainees(c *gin.Context, q rowQuerier, eventID, volunteerID, scheduleID int) bool {
+	dependent, err := hasDependentTrainees(q, eventID, volunteerID, scheduleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar trainees acompanhados",
+		})
+		return false
+	}
+	if dependent {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "O voluntário acompanha trainees neste evento; defina outro parceiro para eles antes",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// GetStaffingRequirements retorna as necessidades de escala, opcionalmente filtradas por evento ou tipo de evento
+func GetStaffingRequirements(c *gin.Context) {
+	query := "SELECT id, event_id, event_type, role_id, count FROM staffing_requirements"
+	var args []interface{}
+
+	if eventIDParam := c.Query("eventId"); eventIDParam != "" {
+		eventID, err := strconv.Atoi(eventIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "ID de evento inválido",
+			})
+			return
+		}
+		query += " WHERE event_id = $1"
+		args = append(args, eventID)
+	} else if eventType := c.Query("eventType"); eventType != "" {
+		query += " WHERE event_id IS NULL AND event_type = $1"
+		args = append(args, eventType)
+	}
+	query += " ORDER BY event_id NULLS FIRST, event_type, role_id"
+
+	rows, err := db.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar necessidades de escala",
+		})
+		return
+	}
+	defer rows.Close()
+
+	var requirements []models.StaffingRequirement
+	for rows.Next() {
+		var requirement models.StaffingRequirement
+		if err := rows.Scan(&requirement.ID, &requirement.EventID, &requirement.EventType,
+			&requirement.RoleID, &requirement.Count); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar necessidades de escala",
+			})
+			return
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    requirements,
+	})
+}
+
+// CreateStaffingRequirement cria uma necessidade de escala para um evento ou tipo de evento
+func CreateStaffingRequirement(c *gin.Context) {
+	var requirementRequest models.StaffingRequirementRequest
+
+	if err := c.ShouldBindJSON(&requirementRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	if status, msg := validateStaffingRequirementRequest(requirementRequest, 0); msg != "" {
+		c.JSON(status, models.ApiResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireRoleTeamScope(c, requirementRequest.RoleID) {
+		return
+	}
+
+	var requirement models.StaffingRequirement
+	err := db.DB.QueryRow(context.Background(),
+		`INSERT INTO staffing_requirements (event_id, event_type, role_id, count)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, event_id, event_type, role_id, count`,
+		requirementRequest.EventID, requirementRequest.EventType, requirementRequest.RoleID, requirementRequest.Count).
+		Scan(&requirement.ID, &requirement.EventID, &requirement.EventType, &requirement.RoleID, &requirement.Count)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar necessidade de escala",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Success: true,
+		Message: "Necessidade de escala criada com sucesso",
+		Data:    requirement,
+	})
+}
+
+// UpdateStaffingRequirement atualiza uma necessidade de escala existente
+func UpdateStaffingRequirement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var requirementRequest models.StaffingRequirementRequest
+	if err := c.ShouldBindJSON(&requirementRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Verificar se a necessidade existe
+	var exists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM staffing_requirements WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar necessidade de escala",
+		})
+		return
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Necessidade de escala não encontrada",
+		})
+		return
+	}
+
+	if status, msg := validateStaffingRequirementRequest(requirementRequest, id); msg != "" {
+		c.JSON(status, models.ApiResponse{
+			Success: false,
+			Error:   msg,
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !(requireStaffingTeamScope(c, id) && requireRoleTeamScope(c, requirementRequest.RoleID)) {
+		return
+	}
+
+	var requirement models.StaffingRequirement
+	err = db.DB.QueryRow(context.Background(),
+		`UPDATE staffing_requirements
+		 SET event_id = $1, event_type = $2, role_id = $3, count = $4
+		 WHERE id = $5
+		 RETURNING id, event_id, event_type, role_id, count`,
+		requirementRequest.EventID, requirementRequest.EventType, requirementRequest.RoleID, requirementRequest.Count, id).
+		Scan(&requirement.ID, &requirement.EventID, &requirement.EventType, &requirement.RoleID, &requirement.Count)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar necessidade de escala",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Necessidade de escala atualizada com sucesso",
+		Data:    requirement,
+	})
+}
+
+// DeleteStaffingRequirement remove uma necessidade de escala
+func DeleteStaffingRequirement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireStaffingTeamScope(c, id) {
+		return
+	}
+
+	result, err := db.DB.Exec(context.Background(), "DELETE FROM staffing_requirements WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao excluir necessidade de escala",
+		})
+		return
+	}
+
+	if result.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Necessidade de escala não encontrada",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Necessidade de escala excluída com sucesso",
+	})
+}
+
+// GetEventCoverage compara as necessidades de escala de um evento com os voluntários escalados
+func GetEventCoverage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	coverages, err := loadEventCoverage("e.id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao calcular cobertura da escala",
+		})
+		return
+	}
+
+	if len(coverages) == 0 {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Evento não encontrado",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    coverages[0],
+	})
+}
+
+// GetCoverage lista a cobertura da escala dos eventos de um período (padrão: próximos 30 dias).
+// Com openOnly=true, retorna apenas eventos com vagas em aberto.
+func GetCoverage(c *gin.Context) {
+	startDate := truncateToDay(time.Now())
+	endDate := startDate.AddDate(0, 0, 30)
+
+	if value := c.Query("startDate"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Data inicial inválida: use o formato AAAA-MM-DD",
+			})
+			return
+		}
+		startDate = parsed
+	}
+
+	if value := c.Query("endDate"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Success: false,
+				Error:   "Data final inválida: use o formato AAAA-MM-DD",
+			})
+			return
+		}
+		endDate = parsed
+	}
+
+	coverages, err := loadEventCoverage("e.event_date >= $1 AND e.event_date < $2", startDate, endDate.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao calcular cobertura da escala",
+		})
+		return
+	}
+
+	if c.Query("openOnly") == "true" {
+		var open []models.EventCoverage
+		for _, coverage := range coverages {
+			if coverage.OpenSlots > 0 {
+				open = append(open, coverage)
+			}
+		}
+		coverages = open
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    coverages,
+	})
+}
+
+// validateStaffingRequirementRequest valida uma necessidade de escala.
+// ignoreID exclui a própria necessidade da verificação de duplicidade (0 na criação).
+func validateStaffingRequirementRequest(req models.StaffingRequirementRequest, ignoreID int) (int, string) {
+	if (req.EventID == nil) == (req.EventType == nil || *req.EventType == "") {
+		return http.StatusBadRequest, "Informe um evento ou um tipo de evento (apenas um dos dois)"
+	}
+
+	var roleExists bool
+	err := db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)", req.RoleID).Scan(&roleExists)
+	if err != nil {
+		return http.StatusInternalServerError, "Erro ao verificar papel"
+	}
+	if !roleExists {
+		return http.StatusBadRequest, "Papel não encontrado"
+	}
+
+	var duplicate bool
+	if req.EventID != nil {
+		var eventExists bool
+		err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)", *req.EventID).Scan(&eventExists)
+		if err != nil {
+			return http.StatusInternalServerError, "Erro ao verificar evento"
+		}
+		if !eventExists {
+			return http.StatusBadRequest, "Evento não encontrado"
+		}
+
+		err = db.DB.QueryRow(context.Background(),
+			"SELECT EXISTS(SELECT 1 FROM staffing_requirements WHERE event_id = $1 AND role_id = $2 AND id != $3)",
+			*req.EventID, req.RoleID, ignoreID).Scan(&duplicate)
+	} else {
+		err = db.DB.QueryRow(context.Background(),
+			"SELECT EXISTS(SELECT 1 FROM staffing_requirements WHERE event_id IS NULL AND event_type = $1 AND role_id = $2 AND id != $3)",
+			*req.EventType, req.RoleID, ignoreID).Scan(&duplicate)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Erro ao verificar necessidade de escala existente"
+	}
+	if duplicate {
+		return http.StatusBadRequest, "Já existe uma necessidade de escala para este papel"
+	}
+
+	return 0, ""
+}
+
+// loadStaffingRequirements resolve a necessidade efetiva de cada papel nos eventos informados
+// (evento -> papel -> quantidade). Necessidades do próprio evento prevalecem sobre as do tipo de evento.
+func loadStaffingRequirements(eventIDs []int) (map[int]map[int]int, error) {
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT e.id, sr.role_id, sr.count, sr.event_id IS NOT NULL
+		 FROM events e
+		 JOIN staffing_requirements sr
+		   ON sr.event_id = e.id OR (sr.event_id IS NULL AND sr.event_type = e.event_type)
+		 WHERE e.id = ANY($1)`, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := map[int]map[int]int{}
+	specific := map[[2]int]bool{}
+	for rows.Next() {
+		var eventID, roleID, count int
+		var isSpecific bool
+		if err := rows.Scan(&eventID, &roleID, &count, &isSpecific); err != nil {
+			return nil, err
+		}
+
+		key := [2]int{eventID, roleID}
+		if specific[key] && !isSpecific {
+			continue
+		}
+		if isSpecific {
+			specific[key] = true
+		}
+		if requirements[eventID] == nil {
+			requirements[eventID] = map[int]int{}
+		}
+		requirements[eventID][roleID] = count
+	}
+
+	return requirements, rows.Err()
+}
+
+// requiredStaffing retorna a necessidade do papel no evento. Papéis sem necessidade cadastrada (no evento ou
+// no tipo de evento) não têm vagas: ficam fora da cobertura e não são preenchidos pela geração de escala.
+func requiredStaffing(requirements map[int]map[int]int, eventID, roleID int) (int, bool) {
+	required, ok := requirements[eventID][roleID]
+	return required, ok
+}
+
+// loadEventCoverage calcula a cobertura dos eventos que satisfazem o filtro (sobre o alias "e").
+// Trainees acompanham outro voluntário e por isso não ocupam vagas.
+func loadEventCoverage(filter string, args ...interface{}) ([]models.EventCoverage, error) {
+	rows, err := db.DB.Query(context.Background(),
+		`SELECT e.id, e.title, e.event_date, e.event_type FROM events e WHERE `+filter+` ORDER BY e.event_date, e.id`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var coverages []models.EventCoverage
+	var eventIDs []int
+	for rows.Next() {
+		var coverage models.EventCoverage
+		if err := rows.Scan(&coverage.EventID, &coverage.EventTitle, &coverage.EventDate, &coverage.EventType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		coverage.Roles = []models.RoleCoverage{}
+		coverages = append(coverages, coverage)
+		eventIDs = append(eventIDs, coverage.EventID)
+	}
+	rows.Close()
+
+	if len(eventIDs) == 0 {
+		return coverages, nil
+	}
+
+	requirements, err := loadStaffingRequirements(eventIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Voluntários escalados (não cancelados, sem contar trainees) por evento e papel
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT s.event_id, v.role_id, COUNT(*)
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 WHERE s.event_id = ANY($1) AND s.status != 'cancelled' AND COALESCE(v.is_trainee, false) = false
+		 GROUP BY s.event_id, v.role_id`, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	scheduled := map[[2]int]int{}
+	for rows.Next() {
+		var eventID, roleID, count int
+		if err := rows.Scan(&eventID, &roleID, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		scheduled[[2]int{eventID, roleID}] = count
+	}
+	rows.Close()
+
+	// Nomes de papéis e times envolvidos
+	rows, err = db.DB.Query(context.Background(),
+		`SELECT r.id, r.name, t.id, t.name
+		 FROM roles r
+		 JOIN teams t ON r.team_id = t.id
+		 ORDER BY t.name, r.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []models.RoleCoverage
+	for rows.Next() {
+		var role models.RoleCoverage
+		if err := rows.Scan(&role.RoleID, &role.RoleName, &role.TeamID, &role.TeamName); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range coverages {
+		coverage := &coverages[i]
+		for _, role := range roles {
+			required, ok := requiredStaffing(requirements, coverage.EventID, role.RoleID)
+			if !ok {
+				continue
+			}
+			role.Required = required
+			role.Scheduled = scheduled[[2]int{coverage.EventID, role.RoleID}]
+			if role.Scheduled < role.Required {
+				role.Open = role.Required - role.Scheduled
+			}
+			coverage.OpenSlots += role.Open
+			coverage.Roles = append(coverage.Roles, role)
+		}
+		coverage.FullyStaffed = coverage.OpenSlots == 0
+	}
+
+	return coverages, nil
+}
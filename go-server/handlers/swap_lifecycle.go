@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// Status de uma solicitação de troca. O voluntário alvo aceita ou recusa, o líder aprova ou rejeita,
+// o solicitante pode cancelar e solicitações em aberto expiram quando o evento começa.
+const (
+	swapStatusPending          = "pending"
+	swapStatusAcceptedByTarget = "accepted_by_target"
+	swapStatusDeclinedByTarget = "declined_by_target"
+	swapStatusApproved         = "approved"
+	swapStatusRejected         = "rejected"
+	swapStatusCancelled        = "cancelled"
+	swapStatusExpired          = "expired"
+)
+
+// swapTransitions lista os status que podem suceder cada status; os demais são finais
+var swapTransitions = map[string][]string{
+	swapStatusPending: {swapStatusAcceptedByTarget, swapStatusDeclinedByTarget, swapStatusApproved,
+		swapStatusRejected, swapStatusCancelled, swapStatusExpired},
+	swapStatusAcceptedByTarget: {swapStatusApproved, swapStatusRejected, swapStatusCancelled, swapStatusExpired},
+}
+
+// swapStatusLabels traduz os status para as mensagens de erro
+var swapStatusLabels = map[string]string{
+	swapStatusPending:          "pendente",
+	swapStatusAcceptedByTarget: "aceita pelo voluntário alvo",
+	swapStatusDeclinedByTarget: "recusada pelo voluntário alvo",
+	swapStatusApproved:         "aprovada",
+	swapStatusRejected:         "rejeitada",
+	swapStatusCancelled:        "cancelada",
+	swapStatusExpired:          "expirada",
+}
+
+// isValidSwapTransition indica se a solicitação pode passar de um status para outro
+func isValidSwapTransition(from, to string) bool {
+	for _, next := range swapTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// swapRequestState reúne os dados de uma solicitação de troca necessários para mudar seu status
+type swapRequestState struct {
+	ID                   int
+	Status               string
+	RequestorScheduleID  int
+	TargetScheduleID     *int
+	TargetVolunteerID    *int
+	RequestorVolunteerID int
+	RequestorUserID      int
+	RequestorLeaderID    *int
+	RequestorEventID     int
+	RequestorEventTitle  string
+	RequestorCancelled   bool
+	// Voluntário que assume o agendamento do solicitante (dono do agendamento alvo ou voluntário alvo)
+	CounterpartVolunteerID *int
+	CounterpartUserID      *int
+	TargetEventID          *int
+	// O evento de algum dos agendamentos já começou
+	EventStarted bool
+}
+
+// hasTarget indica se há um voluntário que precisa aceitar a troca
+func (s swapRequestState) hasTarget() bool {
+	return s.CounterpartVolunteerID != nil
+}
+
+// beginSwapTransition inicia a transação e carrega a solicitação com bloqueio de linha. Solicitações em
+// aberto cujo evento já começou são marcadas como expiradas. Em caso de falha a resposta já foi enviada.
+func beginSwapTransition(c *gin.Context) (pgx.Tx, swapRequestState, bool) {
+	var swap swapRequestState
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return nil, swap, false
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return nil, swap, false
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT sr.id, sr.status, sr.requestor_schedule_id, sr.target_schedule_id, sr.target_volunteer_id,
+		        s1.volunteer_id, v1.user_id, t1.leader_id, e1.id, e1.title, s1.status = 'cancelled',
+		        v2.id, v2.user_id, s2.event_id,
+		        e1.event_date <= NOW() OR COALESCE(e2.event_date <= NOW(), false)
+		 FROM swap_requests sr
+		 JOIN schedules s1 ON sr.requestor_schedule_id = s1.id
+		 JOIN events e1 ON s1.event_id = e1.id
+		 JOIN volunteers v1 ON s1.volunteer_id = v1.id
+		 JOIN teams t1 ON v1.team_id = t1.id
+		 LEFT JOIN schedules s2 ON sr.target_schedule_id = s2.id
+		 LEFT JOIN events e2 ON s2.event_id = e2.id
+		 LEFT JOIN volunteers v2 ON COALESCE(s2.volunteer_id, sr.target_volunteer_id) = v2.id
+		 WHERE sr.id = $1
+		 FOR UPDATE OF sr`, id).
+		Scan(&swap.ID, &swap.Status, &swap.RequestorScheduleID, &swap.TargetScheduleID, &swap.TargetVolunteerID,
+			&swap.RequestorVolunteerID, &swap.RequestorUserID, &swap.RequestorLeaderID, &swap.RequestorEventID,
+			&swap.RequestorEventTitle, &swap.RequestorCancelled,
+			&swap.CounterpartVolunteerID, &swap.CounterpartUserID, &swap.TargetEventID, &swap.EventStarted)
+	if err == pgx.ErrNoRows {
+		tx.Rollback(context.Background())
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Solicitação de troca não encontrada",
+		})
+		return nil, swap, false
+	}
+	if err != nil {
+		tx.Rollback(context.Background())
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar solicitação de troca",
+		})
+		return nil, swap, false
+	}
+
+	if swap.EventStarted && isValidSwapTransition(swap.Status, swapStatusExpired) {
+		_, err = tx.Exec(context.Background(),
+			"UPDATE swap_requests SET status = $1 WHERE id = $2", swapStatusExpired, swap.ID)
+		if err == nil {
+			err = tx.Commit(context.Background())
+		}
+		if err != nil {
+			tx.Rollback(context.Background())
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao atualizar solicitação de troca",
+			})
+			return nil, swap, false
+		}
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   "A solicitação de troca expirou: o evento já começou",
+		})
+		return nil, swap, false
+	}
+
+	return tx, swap, true
+}
+
+// requireSwapTransition responde 409 se a solicitação não puder passar para o novo status
+func requireSwapTransition(c *gin.Context, swap swapRequestState, to, action string) bool {
+	if isValidSwapTransition(swap.Status, to) {
+		return true
+	}
+	c.JSON(http.StatusConflict, models.ApiResponse{
+		Success: false,
+		Error:   "Não é possível " + action + " uma solicitação " + swapStatusLabels[swap.Status],
+	})
+	return false
+}
+
+// requireSwapParticipant exige que o usuário logado seja o voluntário informado.
+// Sem identidade no contexto (AUTH_MODE=off) não há restrição.
+func requireSwapParticipant(c *gin.Context, userID *int, message string) bool {
+	current, exists := c.Get("userID")
+	if !exists || (userID != nil && current == *userID) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, models.ApiResponse{
+		Success: false,
+		Error:   message,
+	})
+	return false
+}
+
+// requireNoSwapConflicts verifica, dentro da transação, se a troca criaria conflito de horário para o
+// solicitante (no evento do agendamento alvo) ou para quem assume o agendamento do solicitante.
+// Os agendamentos trocados são ignorados, pois deixam de pertencer a quem os cede. Os usuários envolvidos
+// ficam bloqueados (lockScheduleUsers) até o fim da transação.
+func requireNoSwapConflicts(c *gin.Context, tx pgx.Tx, swap swapRequestState) bool {
+	if swap.RequestorCancelled {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   "O agendamento do solicitante foi cancelado",
+		})
+		return false
+	}
+
+	volunteerIDs := []int{swap.RequestorVolunteerID}
+	if swap.CounterpartVolunteerID != nil {
+		volunteerIDs = append(volunteerIDs, *swap.CounterpartVolunteerID)
+	}
+	if err := lockScheduleUsers(tx, volunteerIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar conflitos de horário",
+		})
+		return false
+	}
+
+	ignored := []int{swap.RequestorScheduleID}
+	if swap.TargetScheduleID != nil {
+		ignored = append(ignored, *swap.TargetScheduleID)
+	}
+
+	type move struct {
+		volunteerID int
+		eventID     int
+	}
+	var moves []move
+	if swap.CounterpartVolunteerID != nil {
+		moves = append(moves, move{*swap.CounterpartVolunteerID, swap.RequestorEventID})
+	}
+	if swap.TargetEventID != nil {
+		moves = append(moves, move{swap.RequestorVolunteerID, *swap.TargetEventID})
+	}
+
+	for _, m := range moves {
+		volunteerName, eventTitle, err := findSwapConflict(tx, m.volunteerID, m.eventID, ignored)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao verificar conflitos de agendamento",
+			})
+			return false
+		}
+		if eventTitle == "" {
+			continue
+		}
+
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   "A troca criaria um conflito de horário: " + volunteerName + " já está escalado(a) em \"" + eventTitle + "\"",
+		})
+		return false
+	}
+
+	return true
+}
+
+// rowQuerier é atendido tanto pelo pool (db.DB) quanto por uma transação
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// findSwapConflict retorna o nome do voluntário e o título do evento com que ele conflitaria ao assumir
+// o evento informado (título vazio se não houver conflito). Ao contrário de checkSchedulingConflict, já estar
+// escalado no mesmo evento também conta; os agendamentos em ignored, que o voluntário cede, não contam.
+func findSwapConflict(q rowQuerier, volunteerID, eventID int, ignored []int) (string, string, error) {
+	var volunteerName, eventTitle string
+	err := q.QueryRow(context.Background(),
+		`SELECT u.name, e.title
+		 FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 JOIN users u ON v.user_id = u.id
+		 JOIN events e ON s.event_id = e.id
+		 JOIN events target ON target.id = $2
+		 WHERE v.user_id = (SELECT user_id FROM volunteers WHERE id = $1)
+		 AND s.status != 'cancelled' AND s.id != ALL($3)
+		 AND e.event_date < target.event_date + make_interval(mins => target.duration_minutes + $4)
+		 AND e.event_date + make_interval(mins => e.duration_minutes + $4) > target.event_date
+		 ORDER BY e.event_date
+		 LIMIT 1`,
+		volunteerID, eventID, ignored, int(schedulingBuffer()/time.Minute)).Scan(&volunteerName, &eventTitle)
+	if err == pgx.ErrNoRows {
+		return "", "", nil
+	}
+	return volunteerName, eventTitle, err
+}
+
+// updateSwapStatus grava o novo status da solicitação
+func updateSwapStatus(c *gin.Context, tx pgx.Tx, swap swapRequestState, status string) bool {
+	_, err := tx.Exec(context.Background(),
+		"UPDATE swap_requests SET status = $1 WHERE id = $2", status, swap.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar solicitação de troca",
+		})
+		return false
+	}
+	return true
+}
+
+// notifySwapUsers cria a mesma notificação para cada usuário informado, ignorando ausentes e repetidos
+func notifySwapUsers(c *gin.Context, tx pgx.Tx, title, message string, userIDs ...*int) bool {
+	var notified []int
+	for _, userID := range userIDs {
+		if userID == nil || containsInt(notified, *userID) {
+			continue
+		}
+		notified = append(notified, *userID)
+
+		_, err := createNotification(tx, *userID, title, message, "swap_request")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao criar notificação",
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// finishSwapTransition confirma a transação e responde com a solicitação atualizada
+func finishSwapTransition(c *gin.Context, tx pgx.Tx, swapID int, message string) {
+	// Confirmar transação
+	err := tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	// Buscar a solicitação atualizada
+	var swapRequest models.SwapRequest
+	err = db.DB.QueryRow(context.Background(),
+		`SELECT id, requestor_schedule_id, target_schedule_id, target_volunteer_id, reason, status, created_at
+		 FROM swap_requests
+		 WHERE id = $1`, swapID).
+		Scan(&swapRequest.ID, &swapRequest.RequestorScheduleID, &swapRequest.TargetScheduleID,
+			&swapRequest.TargetVolunteerID, &swapRequest.Reason, &swapRequest.Status, &swapRequest.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao obter solicitação atualizada",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    swapRequest,
+	})
+}
+
+// requireSwapTraineePairing verifica, dentro da transação, se quem assume cada agendamento da troca respeita
+// o acompanhamento de trainees já gravado nele
+func requireSwapTraineePairing(c *gin.Context, tx pgx.Tx, swap swapRequestState) bool {
+	if swap.CounterpartVolunteerID != nil &&
+		!requireScheduleTraineePairing(c, tx, *swap.CounterpartVolunteerID, swap.RequestorScheduleID) {
+		return false
+	}
+	if swap.TargetScheduleID != nil &&
+		!requireScheduleTraineePairing(c, tx, swap.RequestorVolunteerID, *swap.TargetScheduleID) {
+		return false
+	}
+	return true
+}
+
+// requireScheduleTraineePairing aplica checkTraineePairing ao voluntário que passaria a ocupar o agendamento,
+// com o parceiro de trainee do agendamento. Responde 409 com o motivo quando a troca não é possível.
+func requireScheduleTraineePairing(c *gin.Context, q rowQuerier, volunteerID, scheduleID int) bool {
+	var eventID int
+	var partnerID *int
+	err := q.QueryRow(context.Background(),
+		"SELECT event_id, trainee_partner_id FROM schedules WHERE id = $1", scheduleID).Scan(&eventID, &partnerID)
+	var issue string
+	if err == nil {
+		issue, err = checkTraineePairing(q, eventID, volunteerID, partnerID, nil)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar parceiro do trainee",
+		})
+		return false
+	}
+	if issue != "" {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   issue,
+		})
+		return false
+	}
+	return true
+}
+
+// StartSwapRequestExpirer marca periodicamente como expiradas as solicitações em aberto cujo evento já
+// começou. As transições também verificam a expiração (beginSwapTransition), então o intervalo só afeta
+// as listagens.
+func StartSwapRequestExpirer(interval time.Duration) {
+	go func() {
+		for {
+			if expired, err := expireSwapRequests(); err != nil {
+				log.Printf("Erro ao expirar solicitações de troca: %v", err)
+			} else if expired > 0 {
+				log.Printf("Solicitações de troca expiradas: %d", expired)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// expireSwapRequests marca como expiradas as solicitações em aberto cujo evento já começou
+func expireSwapRequests() (int64, error) {
+	tag, err := db.DB.Exec(context.Background(),
+		`UPDATE swap_requests sr SET status = $1
+		 FROM schedules s1
+		 JOIN events e1 ON s1.event_id = e1.id
+		 WHERE sr.requestor_schedule_id = s1.id
+		 AND sr.status IN ($2, $3)
+		 AND (e1.event_date <= NOW() OR EXISTS(
+		 	SELECT 1 FROM schedules s2 JOIN events e2 ON s2.event_id = e2.id
+		 	WHERE s2.id = sr.target_schedule_id AND e2.event_date <= NOW()))`,
+		swapStatusExpired, swapStatusPending, swapStatusAcceptedByTarget)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -198,10 +198,8 @@ func CreateSwapRequest(c *gin.Context) {
 		}
 	}
 
-	// Definir status padrão se não fornecido
-	if swapRequestRequest.Status == "" {
-		swapRequestRequest.Status = "pending"
-	}
+	// Toda solicitação começa pendente; os demais status só são alcançados pelas transições
+	swapRequestRequest.Status = swapStatusPending
 
 	// Criar solicitação de troca
 	var swapRequest models.SwapRequest
@@ -292,9 +290,7 @@ func CreateSwapRequest(c *gin.Context) {
 	}
 
 	// Criar notificação
-	_, err = db.DB.Exec(context.Background(),
-		`INSERT INTO notifications (user_id, title, message, type) 
-		 VALUES ($1, $2, $3, $4)`,
+	_, err = createNotification(db.DB,
 		targetUserId, 
 		"Nova solicitação de troca", 
 		"Há uma nova solicitação de troca para o evento " + eventTitle,
@@ -317,126 +313,156 @@ func CreateSwapRequest(c *gin.Context) {
 	})
 }
 
-// ApproveSwapRequest aprova uma solicitação de troca
-func ApproveSwapRequest(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "ID inválido",
-		})
+// AcceptSwapRequest registra que o voluntário alvo aceitou a troca; a solicitação segue para aprovação do líder
+func AcceptSwapRequest(c *gin.Context) {
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
 		return
 	}
+	defer tx.Rollback(context.Background())
 
-	// Verificar se a solicitação existe e está pendente
-	var exists bool
-	var status string
-	err = db.DB.QueryRow(context.Background(), 
-		"SELECT EXISTS(SELECT 1 FROM swap_requests WHERE id = $1), (SELECT status FROM swap_requests WHERE id = $1)", 
-		id).Scan(&exists, &status)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao verificar solicitação de troca",
-		})
+	// Apenas o voluntário alvo pode aceitar a troca
+	if !requireSwapParticipant(c, swap.CounterpartUserID, "Apenas o voluntário alvo pode aceitar a troca") {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
+	if !swap.hasTarget() {
+		c.JSON(http.StatusConflict, models.ApiResponse{
 			Success: false,
-			Error:   "Solicitação de troca não encontrada",
+			Error:   "A solicitação não tem voluntário alvo",
 		})
 		return
 	}
 
-	if status != "pending" {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Só é possível aprovar solicitações pendentes",
-		})
+	if !requireSwapTransition(c, swap, swapStatusAcceptedByTarget, "aceitar") ||
+		!requireNoSwapConflicts(c, tx, swap) ||
+		!updateSwapStatus(c, tx, swap, swapStatusAcceptedByTarget) {
 		return
 	}
 
-	// Iniciar transação
-	tx, err := db.DB.Begin(context.Background())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao iniciar transação",
-		})
+	// Avisar o solicitante e o líder, que precisa aprovar a troca
+	if !notifySwapUsers(c, tx, "Troca aceita",
+		"A troca para o evento "+swap.RequestorEventTitle+" foi aceita e aguarda aprovação do líder",
+		&swap.RequestorUserID, swap.RequestorLeaderID) {
+		return
+	}
+
+	finishSwapTransition(c, tx, swap.ID, "Solicitação de troca aceita com sucesso")
+}
+
+// DeclineSwapRequest registra que o voluntário alvo recusou a troca
+func DeclineSwapRequest(c *gin.Context) {
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
 		return
 	}
 	defer tx.Rollback(context.Background())
 
-	// Atualizar status da solicitação
-	_, err = tx.Exec(context.Background(), 
-		"UPDATE swap_requests SET status = 'approved' WHERE id = $1", id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao atualizar solicitação de troca",
-		})
+	// Apenas o voluntário alvo pode recusar a troca
+	if !requireSwapParticipant(c, swap.CounterpartUserID, "Apenas o voluntário alvo pode recusar a troca") {
 		return
 	}
 
-	// Obter dados necessários para a troca
-	var requestorScheduleID int
-	var targetScheduleID, targetVolunteerID *int
-	err = tx.QueryRow(context.Background(),
-		"SELECT requestor_schedule_id, target_schedule_id, target_volunteer_id FROM swap_requests WHERE id = $1", 
-		id).Scan(&requestorScheduleID, &targetScheduleID, &targetVolunteerID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+	if !swap.hasTarget() {
+		c.JSON(http.StatusConflict, models.ApiResponse{
 			Success: false,
-			Error:   "Erro ao obter dados da solicitação",
+			Error:   "A solicitação não tem voluntário alvo",
 		})
 		return
 	}
 
-	// Dados para notificação
-	var requestorUserId int
-	var eventTitle string
-	err = tx.QueryRow(context.Background(),
-		`SELECT v.user_id, e.title
-		 FROM schedules s
-		 JOIN volunteers v ON s.volunteer_id = v.id
-		 JOIN events e ON s.event_id = e.id
-		 WHERE s.id = $1`, requestorScheduleID).Scan(&requestorUserId, &eventTitle)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+	if !requireSwapTransition(c, swap, swapStatusDeclinedByTarget, "recusar") ||
+		!updateSwapStatus(c, tx, swap, swapStatusDeclinedByTarget) {
+		return
+	}
+
+	if !notifySwapUsers(c, tx, "Troca recusada",
+		"Sua solicitação de troca para o evento "+swap.RequestorEventTitle+" foi recusada",
+		&swap.RequestorUserID) {
+		return
+	}
+
+	finishSwapTransition(c, tx, swap.ID, "Solicitação de troca recusada com sucesso")
+}
+
+// CancelSwapRequest cancela a solicitação a pedido do solicitante
+func CancelSwapRequest(c *gin.Context) {
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Apenas o solicitante pode cancelar a própria solicitação
+	if !requireSwapParticipant(c, &swap.RequestorUserID, "Apenas o solicitante pode cancelar a troca") {
+		return
+	}
+
+	if !requireSwapTransition(c, swap, swapStatusCancelled, "cancelar") ||
+		!updateSwapStatus(c, tx, swap, swapStatusCancelled) {
+		return
+	}
+
+	if !notifySwapUsers(c, tx, "Troca cancelada",
+		"A solicitação de troca para o evento "+swap.RequestorEventTitle+" foi cancelada pelo solicitante",
+		swap.CounterpartUserID) {
+		return
+	}
+
+	finishSwapTransition(c, tx, swap.ID, "Solicitação de troca cancelada com sucesso")
+}
+
+// ApproveSwapRequest aprova uma solicitação de troca. Quando há voluntário alvo, ele precisa ter aceitado antes.
+func ApproveSwapRequest(c *gin.Context) {
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Líderes só podem aprovar trocas que envolvam seus times
+	if !requireSwapRequestTeamScope(c, swap.ID) {
+		return
+	}
+
+	if !requireSwapTransition(c, swap, swapStatusApproved, "aprovar") {
+		return
+	}
+
+	if swap.hasTarget() && swap.Status != swapStatusAcceptedByTarget {
+		c.JSON(http.StatusConflict, models.ApiResponse{
 			Success: false,
-			Error:   "Erro ao obter dados para notificação",
+			Error:   "A troca precisa ser aceita pelo voluntário alvo antes da aprovação",
 		})
 		return
 	}
 
-	// Se tivermos um agendamento alvo, trocar os voluntários
-	if targetScheduleID != nil {
-		var targetVolunteerId, requestorVolunteerId int
-		err = tx.QueryRow(context.Background(),
-			"SELECT volunteer_id FROM schedules WHERE id = $1", *targetScheduleID).Scan(&targetVolunteerId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ApiResponse{
-				Success: false,
-				Error:   "Erro ao obter voluntário alvo",
-			})
-			return
-		}
+	// Revalidar conflitos e acompanhamento de trainees: a escala pode ter mudado desde o aceite
+	if !requireNoSwapConflicts(c, tx, swap) {
+		return
+	}
+	if !requireSwapTraineePairing(c, tx, swap) {
+		return
+	}
 
-		err = tx.QueryRow(context.Background(),
-			"SELECT volunteer_id FROM schedules WHERE id = $1", requestorScheduleID).Scan(&requestorVolunteerId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ApiResponse{
-				Success: false,
-				Error:   "Erro ao obter voluntário solicitante",
-			})
-			return
-		}
+	// Quem sai de um evento não pode deixar sozinhos os trainees que acompanha
+	if !requireNoDependentTrainees(c, tx, swap.RequestorEventID, swap.RequestorVolunteerID, swap.RequestorScheduleID) {
+		return
+	}
+	if swap.TargetScheduleID != nil &&
+		!requireNoDependentTrainees(c, tx, *swap.TargetEventID, *swap.CounterpartVolunteerID, *swap.TargetScheduleID) {
+		return
+	}
 
-		// Trocar os voluntários
+	if !updateSwapStatus(c, tx, swap, swapStatusApproved) {
+		return
+	}
+
+	var err error
+	if swap.TargetScheduleID != nil {
+		// Se tivermos um agendamento alvo, trocar os voluntários
 		_, err = tx.Exec(context.Background(),
-			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", targetVolunteerId, requestorScheduleID)
+			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", *swap.CounterpartVolunteerID, swap.RequestorScheduleID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
@@ -446,7 +472,7 @@ func ApproveSwapRequest(c *gin.Context) {
 		}
 
 		_, err = tx.Exec(context.Background(),
-			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", requestorVolunteerId, *targetScheduleID)
+			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", swap.RequestorVolunteerID, *swap.TargetScheduleID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
@@ -454,10 +480,10 @@ func ApproveSwapRequest(c *gin.Context) {
 			})
 			return
 		}
-	} else if targetVolunteerID != nil {
+	} else if swap.TargetVolunteerID != nil {
 		// Se tivermos apenas um voluntário alvo, substituir o voluntário no agendamento do solicitante
 		_, err = tx.Exec(context.Background(),
-			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", *targetVolunteerID, requestorScheduleID)
+			"UPDATE schedules SET volunteer_id = $1 WHERE id = $2", *swap.TargetVolunteerID, swap.RequestorScheduleID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
@@ -468,7 +494,7 @@ func ApproveSwapRequest(c *gin.Context) {
 	} else {
 		// Se não tivermos um alvo, apenas cancelar o agendamento do solicitante
 		_, err = tx.Exec(context.Background(),
-			"UPDATE schedules SET status = 'cancelled' WHERE id = $1", requestorScheduleID)
+			"UPDATE schedules SET status = 'cancelled' WHERE id = $1", swap.RequestorScheduleID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ApiResponse{
 				Success: false,
@@ -478,193 +504,40 @@ func ApproveSwapRequest(c *gin.Context) {
 		}
 	}
 
-	// Criar notificação para o solicitante
-	_, err = tx.Exec(context.Background(),
-		`INSERT INTO notifications (user_id, title, message, type) 
-		 VALUES ($1, $2, $3, $4)`,
-		requestorUserId, 
-		"Solicitação de troca aprovada", 
-		"Sua solicitação de troca para o evento " + eventTitle + " foi aprovada",
-		"swap_request")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao criar notificação",
-		})
-		return
-	}
-
-	// Confirmar transação
-	err = tx.Commit(context.Background())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao confirmar transação",
-		})
+	// Criar notificação para o solicitante e o voluntário alvo
+	if !notifySwapUsers(c, tx, "Solicitação de troca aprovada",
+		"A solicitação de troca para o evento "+swap.RequestorEventTitle+" foi aprovada",
+		&swap.RequestorUserID, swap.CounterpartUserID) {
 		return
 	}
 
-	// Buscar a solicitação atualizada
-	var swapRequest models.SwapRequest
-	err = db.DB.QueryRow(context.Background(),
-		`SELECT id, requestor_schedule_id, target_schedule_id, target_volunteer_id, reason, status, created_at
-		 FROM swap_requests 
-		 WHERE id = $1`, id).
-		Scan(&swapRequest.ID, &swapRequest.RequestorScheduleID, &swapRequest.TargetScheduleID, 
-			&swapRequest.TargetVolunteerID, &swapRequest.Reason, &swapRequest.Status, &swapRequest.CreatedAt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao obter solicitação atualizada",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, models.ApiResponse{
-		Success: true,
-		Message: "Solicitação de troca aprovada com sucesso",
-		Data:    swapRequest,
-	})
+	finishSwapTransition(c, tx, swap.ID, "Solicitação de troca aprovada com sucesso")
 }
 
 // RejectSwapRequest rejeita uma solicitação de troca
 func RejectSwapRequest(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "ID inválido",
-		})
-		return
-	}
-
-	// Verificar se a solicitação existe e está pendente
-	var exists bool
-	var status string
-	err = db.DB.QueryRow(context.Background(), 
-		"SELECT EXISTS(SELECT 1 FROM swap_requests WHERE id = $1), (SELECT status FROM swap_requests WHERE id = $1)", 
-		id).Scan(&exists, &status)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao verificar solicitação de troca",
-		})
-		return
-	}
-
-	if !exists {
-		c.JSON(http.StatusNotFound, models.ApiResponse{
-			Success: false,
-			Error:   "Solicitação de troca não encontrada",
-		})
-		return
-	}
-
-	if status != "pending" {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Success: false,
-			Error:   "Só é possível rejeitar solicitações pendentes",
-		})
-		return
-	}
-
-	// Iniciar transação
-	tx, err := db.DB.Begin(context.Background())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao iniciar transação",
-		})
+	tx, swap, ok := beginSwapTransition(c)
+	if !ok {
 		return
 	}
 	defer tx.Rollback(context.Background())
 
-	// Atualizar status da solicitação
-	_, err = tx.Exec(context.Background(), 
-		"UPDATE swap_requests SET status = 'rejected' WHERE id = $1", id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao atualizar solicitação de troca",
-		})
+	// Líderes só podem rejeitar trocas que envolvam seus times
+	if !requireSwapRequestTeamScope(c, swap.ID) {
 		return
 	}
 
-	// Obter dados necessários para a notificação
-	var requestorScheduleID int
-	err = tx.QueryRow(context.Background(),
-		"SELECT requestor_schedule_id FROM swap_requests WHERE id = $1", 
-		id).Scan(&requestorScheduleID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao obter dados da solicitação",
-		})
+	if !requireSwapTransition(c, swap, swapStatusRejected, "rejeitar") ||
+		!updateSwapStatus(c, tx, swap, swapStatusRejected) {
 		return
 	}
 
-	// Dados para notificação
-	var requestorUserId int
-	var eventTitle string
-	err = tx.QueryRow(context.Background(),
-		`SELECT v.user_id, e.title
-		 FROM schedules s
-		 JOIN volunteers v ON s.volunteer_id = v.id
-		 JOIN events e ON s.event_id = e.id
-		 WHERE s.id = $1`, requestorScheduleID).Scan(&requestorUserId, &eventTitle)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao obter dados para notificação",
-		})
-		return
-	}
-
-	// Criar notificação para o solicitante
-	_, err = tx.Exec(context.Background(),
-		`INSERT INTO notifications (user_id, title, message, type) 
-		 VALUES ($1, $2, $3, $4)`,
-		requestorUserId, 
-		"Solicitação de troca rejeitada", 
-		"Sua solicitação de troca para o evento " + eventTitle + " foi rejeitada",
-		"swap_request")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao criar notificação",
-		})
+	// Criar notificação para o solicitante e o voluntário alvo
+	if !notifySwapUsers(c, tx, "Solicitação de troca rejeitada",
+		"A solicitação de troca para o evento "+swap.RequestorEventTitle+" foi rejeitada",
+		&swap.RequestorUserID, swap.CounterpartUserID) {
 		return
 	}
 
-	// Confirmar transação
-	err = tx.Commit(context.Background())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao confirmar transação",
-		})
-		return
-	}
-
-	// Buscar a solicitação atualizada
-	var swapRequest models.SwapRequest
-	err = db.DB.QueryRow(context.Background(),
-		`SELECT id, requestor_schedule_id, target_schedule_id, target_volunteer_id, reason, status, created_at
-		 FROM swap_requests 
-		 WHERE id = $1`, id).
-		Scan(&swapRequest.ID, &swapRequest.RequestorScheduleID, &swapRequest.TargetScheduleID, 
-			&swapRequest.TargetVolunteerID, &swapRequest.Reason, &swapRequest.Status, &swapRequest.CreatedAt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApiResponse{
-			Success: false,
-			Error:   "Erro ao obter solicitação atualizada",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, models.ApiResponse{
-		Success: true,
-		Message: "Solicitação de troca rejeitada com sucesso",
-		Data:    swapRequest,
-	})
-}
\ No newline at end of file
+	finishSwapTransition(c, tx, swap.ID, "Solicitação de troca rejeitada com sucesso")
+}
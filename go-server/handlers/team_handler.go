@@ -143,6 +143,11 @@ func UpdateTeam(c *gin.Context) {
 		return
 	}
 
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, id) {
+		return
+	}
+
 	// Atualizar equipe
 	var team models.Team
 	err = db.DB.QueryRow(context.Background(),
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/config"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// teamScope descreve os times cujos recursos o usuário logado pode gerenciar
+type teamScope struct {
+	global bool
+	teams  map[int]bool
+}
+
+// errNoIdentity indica uma requisição sem usuário logado em uma rota que exige escopo de time
+var errNoIdentity = errors.New("usuário não autenticado")
+
+// loadTeamScope carrega o escopo do usuário logado: administradores têm acesso global e líderes
+// apenas aos times que lideram (teams.leader_id). Sem identidade no contexto só há acesso global com
+// a autenticação desativada (AUTH_MODE=off); nos demais modos o resultado é errNoIdentity.
+func loadTeamScope(c *gin.Context) (teamScope, error) {
+	role, exists := c.Get("userRole")
+	if !exists {
+		if AppConfig.AuthMode == config.AuthModeOff {
+			return teamScope{global: true}, nil
+		}
+		return teamScope{}, errNoIdentity
+	}
+	if role == "admin" {
+		return teamScope{global: true}, nil
+	}
+
+	scope := teamScope{teams: make(map[int]bool)}
+	userID, _ := c.Get("userID")
+	rows, err := db.DB.Query(context.Background(), "SELECT id FROM teams WHERE leader_id = $1", userID)
+	if err != nil {
+		return scope, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var teamID int
+		if err := rows.Scan(&teamID); err != nil {
+			return scope, err
+		}
+		scope.teams[teamID] = true
+	}
+
+	return scope, rows.Err()
+}
+
+// allows indica se o escopo inclui o time
+func (s teamScope) allows(teamID int) bool {
+	return s.global || s.teams[teamID]
+}
+
+// requireTeamScope exige que o usuário logado lidere todos os times informados.
+// Em caso de falha a resposta já foi enviada e o handler deve apenas retornar.
+func requireTeamScope(c *gin.Context, teamIDs ...int) bool {
+	scope, ok := currentTeamScope(c)
+	if !ok {
+		return false
+	}
+
+	var missing []int
+	for _, teamID := range teamIDs {
+		if !scope.allows(teamID) && !containsInt(missing, teamID) {
+			missing = append(missing, teamID)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	respondMissingTeamScope(c, missing, false)
+	return false
+}
+
+// requireAnyTeamScope exige que o usuário logado lidere ao menos um dos times informados
+// (por exemplo, uma troca entre voluntários de times diferentes)
+func requireAnyTeamScope(c *gin.Context, teamIDs ...int) bool {
+	scope, ok := currentTeamScope(c)
+	if !ok {
+		return false
+	}
+
+	var missing []int
+	for _, teamID := range teamIDs {
+		if scope.allows(teamID) {
+			return true
+		}
+		if !containsInt(missing, teamID) {
+			missing = append(missing, teamID)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	respondMissingTeamScope(c, missing, true)
+	return false
+}
+
+// requireVolunteerTeamScope exige que o usuário logado lidere os times dos voluntários informados
+func requireVolunteerTeamScope(c *gin.Context, volunteerIDs ...int) bool {
+	teamIDs, ok := lookupTeamIDs(c, "SELECT DISTINCT team_id FROM volunteers WHERE id = ANY($1)", volunteerIDs)
+	return ok && requireFoundTeamScope(c, teamIDs)
+}
+
+// requireVolunteerSelfOrTeamScope permite a operação ao próprio voluntário (mesmo usuário logado) ou a quem
+// lidera o time dele. Voluntário inexistente responde 400.
+func requireVolunteerSelfOrTeamScope(c *gin.Context, volunteerID int) bool {
+	var ownerUserID, teamID int
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT user_id, team_id FROM volunteers WHERE id = $1", volunteerID).Scan(&ownerUserID, &teamID)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Voluntário não encontrado",
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar voluntário",
+		})
+		return false
+	}
+
+	if userID, exists := c.Get("userID"); exists && userID == ownerUserID {
+		return true
+	}
+	return requireTeamScope(c, teamID)
+}
+
+// requireRoleTeamScope exige que o usuário logado lidere os times dos papéis informados
+func requireRoleTeamScope(c *gin.Context, roleIDs ...int) bool {
+	teamIDs, ok := lookupTeamIDs(c, "SELECT DISTINCT team_id FROM roles WHERE id = ANY($1)", roleIDs)
+	return ok && requireFoundTeamScope(c, teamIDs)
+}
+
+// requireScheduleTeamScope exige que o usuário logado lidere o time do voluntário de cada agendamento informado
+func requireScheduleTeamScope(c *gin.Context, scheduleIDs ...int) bool {
+	teamIDs, ok := lookupTeamIDs(c,
+		`SELECT DISTINCT v.team_id FROM schedules s
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 WHERE s.id = ANY($1)`, scheduleIDs)
+	return ok && requireFoundTeamScope(c, teamIDs)
+}
+
+// requireStaffingTeamScope exige que o usuário logado lidere o time do papel de cada necessidade de escala informada
+func requireStaffingTeamScope(c *gin.Context, requirementIDs ...int) bool {
+	teamIDs, ok := lookupTeamIDs(c,
+		`SELECT DISTINCT r.team_id FROM staffing_requirements sr
+		 JOIN roles r ON sr.role_id = r.id
+		 WHERE sr.id = ANY($1)`, requirementIDs)
+	return ok && requireFoundTeamScope(c, teamIDs)
+}
+
+// requireFoundTeamScope aplica requireTeamScope aos times encontrados por lookupTeamIDs. Sem nenhum time
+// (recursos inexistentes) só administradores seguem adiante, para que o handler responda 404; os demais
+// usuários têm o acesso negado.
+func requireFoundTeamScope(c *gin.Context, teamIDs []int) bool {
+	if len(teamIDs) > 0 {
+		return requireTeamScope(c, teamIDs...)
+	}
+
+	scope, ok := currentTeamScope(c)
+	if !ok {
+		return false
+	}
+	if scope.global {
+		return true
+	}
+	c.JSON(http.StatusForbidden, models.ApiResponse{
+		Success: false,
+		Error:   "Acesso negado: nenhum time encontrado para o recurso",
+	})
+	return false
+}
+
+// requireSwapRequestTeamScope exige que o usuário logado lidere ao menos um dos times envolvidos na troca
+// (o do solicitante, o do agendamento alvo ou o do voluntário substituto)
+func requireSwapRequestTeamScope(c *gin.Context, swapRequestID int) bool {
+	teamIDs, ok := lookupTeamIDs(c,
+		`SELECT v.team_id FROM swap_requests sr
+		 JOIN schedules s ON s.id IN (sr.requestor_schedule_id, sr.target_schedule_id)
+		 JOIN volunteers v ON s.volunteer_id = v.id
+		 WHERE sr.id = ANY($1)
+		 UNION
+		 SELECT v.team_id FROM swap_requests sr
+		 JOIN volunteers v ON sr.target_volunteer_id = v.id
+		 WHERE sr.id = ANY($1)`, []int{swapRequestID})
+	return ok && requireAnyTeamScope(c, teamIDs...)
+}
+
+// currentTeamScope carrega o escopo respondendo com erro se a consulta falhar
+func currentTeamScope(c *gin.Context) (teamScope, bool) {
+	scope, err := loadTeamScope(c)
+	if err == errNoIdentity {
+		c.JSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+		return scope, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar permissões do time",
+		})
+		return scope, false
+	}
+	return scope, true
+}
+
+// lookupTeamIDs busca os times relacionados aos IDs informados
+func lookupTeamIDs(c *gin.Context, query string, ids []int) ([]int, bool) {
+	rows, err := db.DB.Query(context.Background(), query, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar permissões do time",
+		})
+		return nil, false
+	}
+	defer rows.Close()
+
+	var teamIDs []int
+	for rows.Next() {
+		var teamID int
+		if err := rows.Scan(&teamID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao verificar permissões do time",
+			})
+			return nil, false
+		}
+		teamIDs = append(teamIDs, teamID)
+	}
+	// Uma falha no meio da leitura não pode virar uma lista vazia (e um acesso liberado)
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar permissões do time",
+		})
+		return nil, false
+	}
+
+	return teamIDs, true
+}
+
+// respondMissingTeamScope responde 403 informando quais times o usuário precisaria liderar
+func respondMissingTeamScope(c *gin.Context, teamIDs []int, anyOf bool) {
+	missing := make([]models.TeamScope, 0, len(teamIDs))
+	names := make(map[int]string)
+	rows, err := db.DB.Query(context.Background(), "SELECT id, name FROM teams WHERE id = ANY($1)", teamIDs)
+	if err == nil {
+		for rows.Next() {
+			var teamID int
+			var name string
+			if rows.Scan(&teamID, &name) == nil {
+				names[teamID] = name
+			}
+		}
+		rows.Close()
+	}
+
+	labels := make([]string, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		missing = append(missing, models.TeamScope{TeamID: teamID, TeamName: names[teamID]})
+		if name := names[teamID]; name != "" {
+			labels = append(labels, fmt.Sprintf("%q (ID %d)", name, teamID))
+		} else {
+			labels = append(labels, fmt.Sprintf("ID %d", teamID))
+		}
+	}
+
+	message := "Acesso negado: é necessário ser líder do time " + strings.Join(labels, ", ")
+	if len(labels) > 1 {
+		message = "Acesso negado: é necessário ser líder dos times " + strings.Join(labels, ", ")
+		if anyOf {
+			message = "Acesso negado: é necessário ser líder de um dos times " + strings.Join(labels, ", ")
+		}
+	}
+
+	c.JSON(http.StatusForbidden, models.ApiResponse{
+		Success: false,
+		Error:   message,
+		Data:    missing,
+	})
+}
+
+// containsInt indica se o valor está na lista
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+// traineeProgressQuery resume os serviços acompanhados (agendamentos não cancelados com parceiro) de cada voluntário
+const traineeProgressQuery = `SELECT v.id, v.user_id, u.name, t.id, t.name, r.id, r.name, COALESCE(v.is_trainee, false),
+        COUNT(s.id) FILTER (WHERE e.event_date < NOW()),
+        COUNT(s.id) FILTER (WHERE e.event_date >= NOW()),
+        MAX(e.event_date) FILTER (WHERE e.event_date < NOW())
+ FROM volunteers v
+ JOIN users u ON v.user_id = u.id
+ JOIN teams t ON v.team_id = t.id
+ JOIN roles r ON v.role_id = r.id
+ LEFT JOIN schedules s ON s.volunteer_id = v.id AND s.trainee_partner_id IS NOT NULL AND s.status != 'cancelled'
+ LEFT JOIN events e ON s.event_id = e.id
+ WHERE `
+
+// traineeProgressGroupBy completa traineeProgressQuery depois do filtro
+const traineeProgressGroupBy = `
+ GROUP BY v.id, v.user_id, u.name, t.id, t.name, r.id, r.name, v.is_trainee
+ ORDER BY t.name, u.name`
+
+// GetTrainees lista os trainees dos times do usuário logado com os serviços já acompanhados
+func GetTrainees(c *gin.Context) {
+	scope, ok := currentTeamScope(c)
+	if !ok {
+		return
+	}
+
+	threshold := AppConfig.TraineeGraduationThreshold
+	rows, err := db.DB.Query(context.Background(), traineeProgressQuery+"v.is_trainee = true"+traineeProgressGroupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar trainees",
+		})
+		return
+	}
+	defer rows.Close()
+
+	trainees := []models.TraineeProgress{}
+	for rows.Next() {
+		progress, _, err := scanTraineeProgress(rows, threshold)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao processar trainees",
+			})
+			return
+		}
+		if scope.allows(progress.TeamID) {
+			trainees = append(trainees, progress)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    trainees,
+	})
+}
+
+// GraduateTrainee encerra o período de trainee de um voluntário que já acompanhou o número mínimo de
+// serviços (TRAINEE_GRADUATION_THRESHOLD) e notifica o voluntário
+func GraduateTrainee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	threshold := AppConfig.TraineeGraduationThreshold
+	progress, isTrainee, err := scanTraineeProgress(
+		db.DB.QueryRow(context.Background(), traineeProgressQuery+"v.id = $1"+traineeProgressGroupBy, id), threshold)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Voluntário não encontrado",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar voluntário",
+		})
+		return
+	}
+
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, progress.TeamID) {
+		return
+	}
+
+	if !isTrainee {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error:   "O voluntário não é trainee",
+		})
+		return
+	}
+
+	if !progress.ReadyToGraduate {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Success: false,
+			Error: fmt.Sprintf("O trainee acompanhou %d de %d serviços necessários para a graduação",
+				progress.ShadowedServices, threshold),
+			Data: progress,
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var volunteer models.Volunteer
+	err = tx.QueryRow(context.Background(),
+		`UPDATE volunteers SET is_trainee = false WHERE id = $1
+		 RETURNING id, user_id, team_id, role_id, is_trainee`, id).
+		Scan(&volunteer.ID, &volunteer.UserID, &volunteer.TeamID, &volunteer.RoleID, &volunteer.IsTrainee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar voluntário",
+		})
+		return
+	}
+
+	// Os próximos serviços deixam de ser acompanhados; o histórico continua contando como acompanhado
+	_, err = tx.Exec(context.Background(),
+		`UPDATE schedules SET trainee_partner_id = NULL
+		 WHERE volunteer_id = $1 AND event_id IN (SELECT id FROM events WHERE event_date >= NOW())`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar agendamentos do voluntário",
+		})
+		return
+	}
+
+	_, err = createNotification(tx,
+		progress.UserID,
+		"Parabéns pela graduação!",
+		"Você concluiu o acompanhamento como trainee em "+progress.RoleName+" ("+progress.TeamName+
+			") e já pode servir sem parceiro",
+		"trainee")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao criar notificação",
+		})
+		return
+	}
+
+	// Confirmar transação
+	err = tx.Commit(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	recordAuditLog(c, auditTraineeGraduation, &progress.UserID,
+		fmt.Sprintf("voluntário %d graduado após %d serviços acompanhados", id, progress.ShadowedServices))
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Trainee graduado com sucesso",
+		Data:    volunteer,
+	})
+}
+
+// scanTraineeProgress lê uma linha de traineeProgressQuery, indicando também se o voluntário ainda é trainee
+func scanTraineeProgress(row pgx.Row, threshold int) (models.TraineeProgress, bool, error) {
+	var progress models.TraineeProgress
+	var isTrainee bool
+	err := row.Scan(&progress.VolunteerID, &progress.UserID, &progress.Name, &progress.TeamID, &progress.TeamName,
+		&progress.RoleID, &progress.RoleName, &isTrainee, &progress.ShadowedServices, &progress.UpcomingShadows,
+		&progress.LastShadowedAt)
+	progress.GraduationThreshold = threshold
+	progress.ReadyToGraduate = isTrainee && progress.ShadowedServices >= threshold
+	return progress, isTrainee, err
+}
+
+// checkTraineePairing valida o parceiro de um agendamento: trainees não servem sozinhos e precisam de um
+// parceiro experiente (não trainee) do mesmo time e função, escalado no mesmo evento; voluntários experientes
+// não têm parceiro. planned contém os pares (evento, voluntário) de um rascunho ainda não gravado.
+// Retorna o motivo da recusa, ou vazio se o agendamento for válido.
+func checkTraineePairing(q rowQuerier, eventID, volunteerID int, partnerID *int, planned map[[2]int]bool) (string, error) {
+	var isTrainee bool
+	var teamID, roleID int
+	err := q.QueryRow(context.Background(),
+		"SELECT COALESCE(is_trainee, false), team_id, role_id FROM volunteers WHERE id = $1", volunteerID).
+		Scan(&isTrainee, &teamID, &roleID)
+	if err != nil {
+		return "", err
+	}
+
+	if !isTrainee {
+		if partnerID != nil {
+			return "Apenas trainees podem ter um parceiro de acompanhamento", nil
+		}
+		return "", nil
+	}
+
+	if partnerID == nil {
+		return "Trainees não podem ser escalados sozinhos: informe um parceiro experiente (traineePartnerId)", nil
+	}
+	if *partnerID == volunteerID {
+		return "O trainee não pode ser o próprio parceiro", nil
+	}
+
+	var partnerIsTrainee bool
+	var partnerTeamID, partnerRoleID int
+	err = q.QueryRow(context.Background(),
+		"SELECT COALESCE(is_trainee, false), team_id, role_id FROM volunteers WHERE id = $1", *partnerID).
+		Scan(&partnerIsTrainee, &partnerTeamID, &partnerRoleID)
+	if err == pgx.ErrNoRows {
+		return "Parceiro do trainee não encontrado", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if partnerIsTrainee {
+		return "O parceiro deve ser um voluntário experiente, não um trainee", nil
+	}
+	if partnerTeamID != teamID || partnerRoleID != roleID {
+		return "O parceiro deve ser do mesmo time e função do trainee", nil
+	}
+
+	if planned[[2]int{eventID, *partnerID}] {
+		return "", nil
+	}
+	var partnerScheduled bool
+	err = q.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM schedules
+		 WHERE event_id = $1 AND volunteer_id = $2 AND status != 'cancelled')`,
+		eventID, *partnerID).Scan(&partnerScheduled)
+	if err != nil {
+		return "", err
+	}
+	if !partnerScheduled {
+		return "O parceiro precisa estar escalado no mesmo evento", nil
+	}
+
+	return "", nil
+}
+
+// hasDependentTrainees indica se algum trainee escalado no evento tem o voluntário como parceiro
+// (o agendamento informado é ignorado)
+func hasDependentTrainees(q rowQuerier, eventID, volunteerID, scheduleID int) (bool, error) {
+	var exists bool
+	err := q.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM schedules
+		 WHERE event_id = $1 AND trainee_partner_id = $2 AND status != 'cancelled' AND id != $3)`,
+		eventID, volunteerID, scheduleID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"volunteer-scheduler/db"
+	"volunteer-scheduler/models"
+)
+
+const (
+	defaultUsersPageSize = 20
+	maxUsersPageSize     = 100
+)
+
+// GetUsers retorna os usuários de forma paginada, com filtro opcional por papel de acesso
+func GetUsers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Página inválida",
+		})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultUsersPageSize)))
+	if err != nil || pageSize < 1 {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Tamanho de página inválido",
+		})
+		return
+	}
+	if pageSize > maxUsersPageSize {
+		pageSize = maxUsersPageSize
+	}
+
+	role := c.Query("role")
+	if role != "" && !isValidUserRole(role) {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Papel de acesso inválido",
+		})
+		return
+	}
+
+	// Um filtro vazio significa "todos os papéis"
+	var total int
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM users WHERE ($1 = '' OR role = $1)", role).Scan(&total)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao contar usuários",
+		})
+		return
+	}
+
+	users, err := queryUsers(
+		`SELECT id, username, name, email, role, active, created_at
+		 FROM users
+		 WHERE ($1 = '' OR role = $1)
+		 ORDER BY name, id
+		 LIMIT $2 OFFSET $3`, role, pageSize, (page-1)*pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar usuários",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data: models.PaginatedData{
+			Items:    users,
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
+
+// GetLeaders retorna os usuários ativos com papel de líder (usado na escolha do líder de equipe)
+func GetLeaders(c *gin.Context) {
+	users, err := queryUsers(
+		`SELECT id, username, name, email, role, active, created_at
+		 FROM users
+		 WHERE role = 'leader' AND active = true
+		 ORDER BY name`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao buscar líderes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    users,
+	})
+}
+
+// GetUser retorna um usuário específico pelo ID
+func GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var user models.User
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT id, username, name, email, role, active, created_at FROM users WHERE id = $1", id).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// UpdateUser atualiza os dados de perfil de um usuário
+func UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var userRequest models.UserUpdateRequest
+	if err := c.ShouldBindJSON(&userRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Dados inválidos",
+		})
+		return
+	}
+
+	// Verificar se o usuário existe
+	var exists bool
+	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar usuário",
+		})
+		return
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	// Verificar se o nome de usuário já pertence a outro usuário
+	var usernameTaken bool
+	err = db.DB.QueryRow(context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM users WHERE username = $1 AND id != $2)",
+		userRequest.Username, id).Scan(&usernameTaken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao verificar nome de usuário",
+		})
+		return
+	}
+
+	if usernameTaken {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Nome de usuário já existente",
+		})
+		return
+	}
+
+	// Atualizar usuário
+	var user models.User
+	err = db.DB.QueryRow(context.Background(),
+		`UPDATE users SET username = $1, name = $2, email = $3
+		 WHERE id = $4
+		 RETURNING id, username, name, email, role, active, created_at`,
+		userRequest.Username, userRequest.Name, userRequest.Email, id).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao atualizar usuário",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Usuário atualizado com sucesso",
+		Data:    user,
+	})
+}
+
+// UpdateUserRole altera o papel de acesso (admin, leader, volunteer) de um usuário
+func UpdateUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var roleRequest models.UserRoleRequest
+	if err := c.ShouldBindJSON(&roleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Papel de acesso inválido",
+		})
+		return
+	}
+
+	// Um administrador não pode remover o próprio acesso de administrador
+	if currentUserID, exists := c.Get("userID"); exists && currentUserID == id && roleRequest.Role != "admin" {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Não é possível alterar o próprio papel de acesso",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var previousRole string
+	err = tx.QueryRow(context.Background(), "SELECT role FROM users WHERE id = $1 FOR UPDATE", id).Scan(&previousRole)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	var user models.User
+	err = tx.QueryRow(context.Background(),
+		`UPDATE users SET role = $1
+		 WHERE id = $2
+		 RETURNING id, username, name, email, role, active, created_at`,
+		roleRequest.Role, id).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	// Tokens emitidos com o papel anterior deixam de valer
+	if previousRole != user.Role {
+		if err := revokeUserSessions(tx, id); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao encerrar sessões do usuário",
+			})
+			return
+		}
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: "Papel de acesso atualizado com sucesso",
+		Data:    user,
+	})
+}
+
+// DeactivateUser desativa um usuário, impedindo novos logins sem excluir seus dados
+func DeactivateUser(c *gin.Context) {
+	setUserActive(c, false)
+}
+
+// ActivateUser reativa um usuário desativado
+func ActivateUser(c *gin.Context) {
+	setUserActive(c, true)
+}
+
+// setUserActive altera o campo active do usuário informado na rota
+func setUserActive(c *gin.Context, active bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	if currentUserID, exists := c.Get("userID"); exists && currentUserID == id && !active {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Não é possível desativar o próprio usuário",
+		})
+		return
+	}
+
+	// Iniciar transação
+	tx, err := db.DB.Begin(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao iniciar transação",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var user models.User
+	err = tx.QueryRow(context.Background(),
+		`UPDATE users SET active = $1
+		 WHERE id = $2
+		 RETURNING id, username, name, email, role, active, created_at`,
+		active, id).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Role, &user.Active, &user.CreatedAt)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	// Usuários desativados perdem imediatamente todas as sessões
+	if !active {
+		if err := revokeUserSessions(tx, id); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Success: false,
+				Error:   "Erro ao encerrar sessões do usuário",
+			})
+			return
+		}
+	}
+
+	// Confirmar transação
+	if err := tx.Commit(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Success: false,
+			Error:   "Erro ao confirmar transação",
+		})
+		return
+	}
+
+	message := "Usuário reativado com sucesso"
+	if !active {
+		message = "Usuário desativado com sucesso"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    user,
+	})
+}
+
+// UnlockUser remove o bloqueio de login de um usuário após falhas de senha. Apenas o nome de usuário é
+// desbloqueado; se o IP da última falha também estiver em espera, a resposta informa quanto falta
+// (ipRetryAfterSeconds).
+func UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "ID inválido",
+		})
+		return
+	}
+
+	var username string
+	err = db.DB.QueryRow(context.Background(), "SELECT username FROM users WHERE id = $1", id).Scan(&username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não encontrado",
+		})
+		return
+	}
+
+	if locked, _ := LoginLimiter.IsLocked(username); !locked {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Success: false,
+			Error:   "Usuário não está bloqueado",
+		})
+		return
+	}
+
+	ipWait := LoginLimiter.Unlock(username)
+	recordAuditLog(c, auditLoginUnlock, &id, "Bloqueio de login do usuário "+username+" removido")
+
+	message := "Usuário desbloqueado com sucesso"
+	if ipWait > 0 {
+		message += "; o IP da última tentativa continua bloqueado"
+	}
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    gin.H{"ipRetryAfterSeconds": int(math.Ceil(ipWait.Seconds()))},
+	})
+}
+
+// queryUsers executa uma consulta que retorna as colunas públicas de users (nunca a senha)
+func queryUsers(query string, args ...interface{}) ([]models.User, error) {
+	rows, err := db.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Name, &user.Email,
+			&user.Role, &user.Active, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// isValidUserRole verifica se o papel de acesso é um dos suportados
+func isValidUserRole(role string) bool {
+	return role == "admin" || role == "leader" || role == "volunteer"
+}
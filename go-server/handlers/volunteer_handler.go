@@ -127,6 +127,11 @@ func CreateVolunteer(c *gin.Context) {
 		return
 	}
 
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireTeamScope(c, volunteerRequest.TeamID) {
+		return
+	}
+
 	// Verificar se o papel existe
 	var roleExists bool
 	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)", 
@@ -254,6 +259,11 @@ func UpdateVolunteer(c *gin.Context) {
 		return
 	}
 
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !(requireVolunteerTeamScope(c, id) && requireTeamScope(c, volunteerRequest.TeamID)) {
+		return
+	}
+
 	// Verificar se o papel pertence ao time
 	var roleTeamMatch bool
 	err = db.DB.QueryRow(context.Background(), 
@@ -332,6 +342,11 @@ func DeleteVolunteer(c *gin.Context) {
 		return
 	}
 
+	// Líderes só podem gerenciar recursos dos times que lideram
+	if !requireVolunteerTeamScope(c, id) {
+		return
+	}
+
 	// Verificar dependências (schedules)
 	var hasSchedules bool
 	err = db.DB.QueryRow(context.Background(), "SELECT EXISTS(SELECT 1 FROM schedules WHERE volunteer_id = $1)", id).Scan(&hasSchedules)
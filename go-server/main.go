@@ -4,9 +4,11 @@ import (
         "fmt"
         "log"
         "os"
+        "time"
 
         "github.com/gin-gonic/gin"
         "github.com/joho/godotenv"
+        "volunteer-scheduler/config"
         "volunteer-scheduler/db"
         "volunteer-scheduler/handlers"
         "volunteer-scheduler/utils"
@@ -19,13 +21,57 @@ func main() {
                 log.Println("Arquivo .env não encontrado, usando variáveis de ambiente do sistema")
         }
 
+        cfg := config.LoadConfig()
+        handlers.AppConfig = cfg
+
         // Inicializar conexão com o banco de dados
-        err := db.InitDB()
+        err = db.InitDB()
         if err != nil {
                 log.Fatalf("Erro ao inicializar o banco de dados: %v", err)
         }
         defer db.CloseDB()
 
+        // Validade e chaves de assinatura dos tokens (chaves obrigatórias em produção)
+        utils.AccessTokenTTLMinutes = cfg.AccessTokenTTLMinutes
+        if err := utils.LoadJWTKeys(cfg); err != nil {
+                log.Fatalf("Erro ao carregar chaves JWT: %v", err)
+        }
+
+        // Entrega de mensagens aos usuários (links de redefinição de senha, notificações por e-mail, etc.).
+        // Em produção só o SMTP: os demais remetentes escrevem os links de uso único no log ou em arquivo
+        // e as entregas de notificações seriam marcadas como enviadas sem terem saído
+        if cfg.IsProduction() && cfg.MailSender != "smtp" {
+                log.Fatalf("MAIL_SENDER=%s não é permitido em produção: use smtp", cfg.MailSender)
+        }
+        handlers.MessageSender = utils.NewSender(cfg)
+
+        // SMS e WhatsApp: em produção só com o provedor real; sem ele os canais não podem ser ativados,
+        // para que nenhuma entrega seja marcada como enviada sem ter saído e os telefones não parem no log
+        handlers.TextProvider = utils.NewTextProvider(cfg)
+        if cfg.IsProduction() && cfg.TextProvider != "webhook" {
+                log.Printf("TEXT_PROVIDER=%s em produção: canais de SMS e WhatsApp desativados", cfg.TextProvider)
+                handlers.DisableTextChannels()
+        }
+
+        // Proteção do login contra tentativas repetidas (em memória, por instância)
+        handlers.LoginLimiter = utils.NewLoginLimiter(cfg, utils.NewMemoryLoginAttemptStore())
+        handlers.StartLoginAttemptSweeper(time.Minute)
+
+        // Manter as séries de eventos recorrentes geradas até o horizonte
+        handlers.StartEventSeriesExtender(24 * time.Hour)
+
+        // Enviar as notificações por e-mail (e demais canais externos) enfileiradas
+        handlers.StartNotificationDispatcher(time.Duration(cfg.NotificationDispatchIntervalSeconds) * time.Second)
+
+        // Expirar as solicitações de troca em aberto cujo evento já começou
+        handlers.StartSwapRequestExpirer(time.Minute)
+
+        // Lembrar os voluntários das escalas com a antecedência configurada em cada time
+        handlers.StartReminderScheduler(time.Duration(cfg.ReminderIntervalMinutes) * time.Minute)
+
+        // Repassar aos fluxos SSE desta instância as notificações criadas por qualquer instância
+        handlers.StartNotificationListener()
+
         // Definir modo do Gin
         if os.Getenv("NODE_ENV") == "production" {
                 gin.SetMode(gin.ReleaseMode)
@@ -36,6 +82,11 @@ func main() {
         // Inicializar router
         router := gin.Default()
 
+        // IP do cliente (limite de login por IP): X-Forwarded-For só vale quando vem do proxy configurado
+        if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+                log.Fatalf("TRUSTED_PROXIES inválido: %v", err)
+        }
+
         // Configurar CORS
         router.Use(func(c *gin.Context) {
                 c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -52,7 +103,10 @@ func main() {
         })
 
         // Configurar rotas
-        setupRoutes(router)
+        if cfg.AuthMode == config.AuthModeOff {
+                log.Println("ATENÇÃO: autenticação desativada (AUTH_MODE=off); use apenas em desenvolvimento")
+        }
+        setupRoutes(router, cfg)
 
         // Obter porta do ambiente ou usar padrão 5000 (mesma do Node.js)
         port := os.Getenv("PORT")
@@ -68,7 +122,7 @@ func main() {
         }
 }
 
-func setupRoutes(router *gin.Engine) {
+func setupRoutes(router *gin.Engine, cfg config.Config) {
         // Rotas públicas
         router.GET("/api/health", func(c *gin.Context) {
                 c.JSON(200, gin.H{
@@ -77,20 +131,61 @@ func setupRoutes(router *gin.Engine) {
                 })
         })
 
+        // Chaves públicas para que o Node.js e outros serviços verifiquem os tokens emitidos pelo Go
+        router.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
+        // Situação das mensagens de SMS/WhatsApp informada pelo provedor (autenticada pelo token na URL)
+        router.POST("/api/notifications/deliveries/status", handlers.UpdateNotificationDeliveryStatus)
+        router.GET("/api/notifications/deliveries/status", handlers.VerifyNotificationDeliveryWebhook)
+
+        // Fluxo de notificações em tempo real (SSE). O EventSource do navegador não envia cabeçalhos,
+        // então o token de acesso também é aceito no parâmetro access_token
+        streamHandlers := []gin.HandlerFunc{utils.AccessTokenFromQuery()}
+        if cfg.AuthMode != config.AuthModeOff {
+                streamHandlers = append(streamHandlers, utils.AuthMiddleware(cfg))
+        }
+        router.GET("/api/notifications/stream", append(streamHandlers, handlers.StreamNotifications)...)
+
         // Rotas de autenticação
         authRoutes := router.Group("/api/auth")
         {
                 authRoutes.POST("/login", handlers.Login)
                 authRoutes.POST("/register", handlers.Register)
+                authRoutes.POST("/refresh", handlers.RefreshToken)
+                authRoutes.POST("/logout", handlers.Logout)
+                authRoutes.POST("/password-reset", handlers.RequestPasswordReset)
+                authRoutes.POST("/password-reset/confirm", handlers.ResetPassword)
+                authRoutes.GET("/invitations/:token", handlers.GetInvitationByToken)
+                authRoutes.POST("/invitations/accept", handlers.AcceptInvitation)
         }
 
-        // Rotas protegidas (temporariamente sem autenticação para transição)
+        // Feeds iCalendar (autenticados pelo token na URL, pois aplicativos de calendário não enviam cabeçalhos)
+        calendarRoutes := router.Group("/api/calendar")
+        {
+                calendarRoutes.GET("/:token/schedules.ics", handlers.GetVolunteerCalendar)
+                calendarRoutes.GET("/:token/teams/:teamId/schedules.ics", handlers.GetTeamCalendar)
+        }
+
+        // Rotas protegidas (JWT ou sessão do Node.js repassada pelo proxy, conforme AUTH_MODE)
         protectedRoutes := router.Group("/api")
-        // TODO: Re-habilitar middleware de autenticação após a migração completa
-        // protectedRoutes.Use(utils.AuthMiddleware())
+        if cfg.AuthMode != config.AuthModeOff {
+                protectedRoutes.Use(utils.AuthMiddleware(cfg))
+        }
         {
                 // Perfil do usuário
                 protectedRoutes.GET("/profile", handlers.GetProfile)
+                protectedRoutes.PUT("/profile/phone", handlers.UpdateProfilePhone)
+                
+                // Encerrar as sessões do usuário em todos os dispositivos
+                protectedRoutes.POST("/auth/logout-all", handlers.LogoutAll)
+                protectedRoutes.POST("/auth/change-password", handlers.ChangePassword)
+                
+                // Endereços dos feeds iCalendar do usuário
+                protectedRoutes.GET("/calendar/feed", handlers.GetCalendarFeed)
+                protectedRoutes.POST("/calendar/feed/reset", handlers.ResetCalendarFeed)
+                
+                // Líderes disponíveis (usado na escolha do líder de equipe)
+                protectedRoutes.GET("/users/leaders", handlers.GetLeaders)
                 
                 // Rotas do painel
                 protectedRoutes.GET("/dashboard/stats", handlers.GetDashboardStats)
@@ -100,11 +195,26 @@ func setupRoutes(router *gin.Engine) {
                 protectedRoutes.GET("/teams", handlers.GetTeams)
                 protectedRoutes.GET("/teams/:id", handlers.GetTeam)
                 protectedRoutes.GET("/teams/with-roles", handlers.GetTeamsWithRoles)
+                protectedRoutes.GET("/teams/:id/reminders", handlers.GetTeamReminderSettings)
+                
+                // Rotas de papéis
+                protectedRoutes.GET("/roles", handlers.GetRoles)
+                protectedRoutes.GET("/roles/:id", handlers.GetRole)
+                protectedRoutes.GET("/roles/team/:teamId", handlers.GetRolesByTeam)
                 
                 // Rotas de eventos
                 protectedRoutes.GET("/events", handlers.GetEvents)
                 protectedRoutes.GET("/events/:id", handlers.GetEvent)
                 protectedRoutes.GET("/events/upcoming", handlers.GetUpcomingEvents)
+                protectedRoutes.GET("/events/:id/coverage", handlers.GetEventCoverage)
+                
+                // Rotas de séries de eventos recorrentes
+                protectedRoutes.GET("/event-series", handlers.GetEventSeries)
+                protectedRoutes.GET("/event-series/:id", handlers.GetEventSeriesByID)
+                
+                // Rotas de necessidades de escala e cobertura
+                protectedRoutes.GET("/staffing-requirements", handlers.GetStaffingRequirements)
+                protectedRoutes.GET("/coverage", handlers.GetCoverage)
                 
                 // Rotas de voluntários
                 protectedRoutes.GET("/volunteers", handlers.GetVolunteers)
@@ -115,52 +225,115 @@ func setupRoutes(router *gin.Engine) {
                 // Rotas de agendamentos
                 protectedRoutes.GET("/schedules", handlers.GetSchedules)
                 protectedRoutes.GET("/schedules/:id", handlers.GetSchedule)
+                protectedRoutes.GET("/schedules/:id/replacements", handlers.GetReplacementSuggestions)
                 protectedRoutes.GET("/schedules/event/:eventId", handlers.GetSchedulesByEvent)
                 protectedRoutes.GET("/schedules/volunteer/:volunteerId", handlers.GetSchedulesByVolunteer)
                 
+                // Rotas de regras de disponibilidade
+                protectedRoutes.GET("/availability-rules", handlers.GetAvailabilityRules)
+                protectedRoutes.GET("/availability-rules/:id", handlers.GetAvailabilityRule)
+                protectedRoutes.GET("/availability-rules/volunteer/:volunteerId", handlers.GetAvailabilityRulesByVolunteer)
+                protectedRoutes.POST("/availability-rules", handlers.CreateAvailabilityRule)
+                protectedRoutes.PUT("/availability-rules/:id", handlers.UpdateAvailabilityRule)
+                protectedRoutes.DELETE("/availability-rules/:id", handlers.DeleteAvailabilityRule)
+                
                 // Rotas de solicitações de troca
                 protectedRoutes.GET("/swap-requests", handlers.GetSwapRequests)
+                protectedRoutes.GET("/swap-requests/open", handlers.GetOpenShifts)
                 protectedRoutes.GET("/swap-requests/:id", handlers.GetSwapRequest)
                 protectedRoutes.POST("/swap-requests", handlers.CreateSwapRequest)
+                // O voluntário alvo aceita ou recusa; o solicitante pode cancelar
+                protectedRoutes.PUT("/swap-requests/:id/accept", handlers.AcceptSwapRequest)
+                protectedRoutes.PUT("/swap-requests/:id/decline", handlers.DeclineSwapRequest)
+                protectedRoutes.PUT("/swap-requests/:id/cancel", handlers.CancelSwapRequest)
+                protectedRoutes.PUT("/swap-requests/:id/claim", handlers.ClaimOpenShift)
                 
                 // Rotas de notificações
                 protectedRoutes.GET("/notifications", handlers.GetNotifications)
                 protectedRoutes.GET("/notifications/unread/count", handlers.GetUnreadNotificationsCount)
+                protectedRoutes.GET("/notifications/preferences", handlers.GetNotificationPreferences)
+                protectedRoutes.PUT("/notifications/preferences", handlers.UpdateNotificationPreference)
                 protectedRoutes.PUT("/notifications/:id/read", handlers.MarkNotificationAsRead)
                 protectedRoutes.PUT("/notifications/read-all", handlers.MarkAllNotificationsAsRead)
                 protectedRoutes.DELETE("/notifications/:id", handlers.DeleteNotification)
                 
-                // Rotas protegidas para admins/líderes (temporariamente sem verificação)
+                // Rotas protegidas para admins/líderes (os handlers limitam líderes aos times que lideram)
                 adminRoutes := protectedRoutes.Group("")
-                // TODO: Re-habilitar middleware de verificação de admin/líder após a migração completa
-                // adminRoutes.Use(utils.IsAdminOrLeader())
+                if cfg.AuthMode != config.AuthModeOff {
+                        adminRoutes.Use(utils.IsAdminOrLeader())
+                }
                 {
-                        // Gerenciamento de equipes
-                        adminRoutes.POST("/teams", handlers.CreateTeam)
+                        // Gerenciamento de equipes (líderes editam apenas as equipes que lideram)
                         adminRoutes.PUT("/teams/:id", handlers.UpdateTeam)
-                        adminRoutes.DELETE("/teams/:id", handlers.DeleteTeam)
+                        adminRoutes.PUT("/teams/:id/reminders", handlers.UpdateTeamReminderSettings)
+                        
+                        // Gerenciamento de papéis
+                        adminRoutes.POST("/roles", handlers.CreateRole)
+                        adminRoutes.PUT("/roles/:id", handlers.UpdateRole)
+                        adminRoutes.DELETE("/roles/:id", handlers.DeleteRole)
                         
                         // Gerenciamento de eventos
                         adminRoutes.POST("/events", handlers.CreateEvent)
                         adminRoutes.PUT("/events/:id", handlers.UpdateEvent)
                         adminRoutes.DELETE("/events/:id", handlers.DeleteEvent)
                         
+                        // Gerenciamento de séries de eventos recorrentes
+                        adminRoutes.POST("/event-series", handlers.CreateEventSeries)
+                        adminRoutes.PUT("/event-series/:id", handlers.UpdateEventSeries)
+                        adminRoutes.DELETE("/event-series/:id", handlers.DeleteEventSeries)
+                        adminRoutes.POST("/event-series/:id/extend", handlers.ExtendEventSeries)
+                        adminRoutes.POST("/event-series/extend", handlers.ExtendAllEventSeries)
+                        
+                        // Gerenciamento de necessidades de escala
+                        adminRoutes.POST("/staffing-requirements", handlers.CreateStaffingRequirement)
+                        adminRoutes.PUT("/staffing-requirements/:id", handlers.UpdateStaffingRequirement)
+                        adminRoutes.DELETE("/staffing-requirements/:id", handlers.DeleteStaffingRequirement)
+                        
                         // Gerenciamento de voluntários
                         adminRoutes.POST("/volunteers", handlers.CreateVolunteer)
                         adminRoutes.PUT("/volunteers/:id", handlers.UpdateVolunteer)
                         adminRoutes.DELETE("/volunteers/:id", handlers.DeleteVolunteer)
+                        adminRoutes.GET("/volunteers/trainees", handlers.GetTrainees)
+                        adminRoutes.PUT("/volunteers/:id/graduate", handlers.GraduateTrainee)
                         
                         // Gerenciamento de agendamentos
                         adminRoutes.POST("/schedules", handlers.CreateSchedule)
                         adminRoutes.PUT("/schedules/:id", handlers.UpdateSchedule)
                         adminRoutes.DELETE("/schedules/:id", handlers.DeleteSchedule)
+                        adminRoutes.POST("/schedules/generate", handlers.GenerateScheduleDraft)
+                        adminRoutes.POST("/schedules/generate/commit", handlers.CommitScheduleDraft)
                         
                         // Gerenciamento de solicitações de troca
                         adminRoutes.PUT("/swap-requests/:id/approve", handlers.ApproveSwapRequest)
                         adminRoutes.PUT("/swap-requests/:id/reject", handlers.RejectSwapRequest)
                         
+                        // Convites de novos voluntários
+                        adminRoutes.GET("/invitations", handlers.GetInvitations)
+                        adminRoutes.POST("/invitations", handlers.CreateInvitation)
+                        adminRoutes.DELETE("/invitations/:id", handlers.RevokeInvitation)
+                        
                         // Gerenciamento de notificações (criar para outros usuários)
                         adminRoutes.POST("/notifications", handlers.CreateNotification)
                 }
+                
+                // Rotas exclusivas de administradores
+                adminOnlyRoutes := protectedRoutes.Group("")
+                if cfg.AuthMode != config.AuthModeOff {
+                        adminOnlyRoutes.Use(utils.IsAdmin())
+                }
+                {
+                        // Criação e exclusão de equipes
+                        adminOnlyRoutes.POST("/teams", handlers.CreateTeam)
+                        adminOnlyRoutes.DELETE("/teams/:id", handlers.DeleteTeam)
+                        
+                        // Gerenciamento de usuários
+                        adminOnlyRoutes.GET("/users", handlers.GetUsers)
+                        adminOnlyRoutes.GET("/users/:id", handlers.GetUser)
+                        adminOnlyRoutes.PUT("/users/:id", handlers.UpdateUser)
+                        adminOnlyRoutes.PUT("/users/:id/role", handlers.UpdateUserRole)
+                        adminOnlyRoutes.PUT("/users/:id/deactivate", handlers.DeactivateUser)
+                        adminOnlyRoutes.PUT("/users/:id/activate", handlers.ActivateUser)
+                        adminOnlyRoutes.PUT("/users/:id/unlock", handlers.UnlockUser)
+                }
         }
 }
\ No newline at end of file
@@ -12,6 +12,8 @@ type User struct {
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
 	Role      string    `json:"role"`
+	Active    bool      `json:"active"`
+	Phone     string    `json:"phone,omitempty"` // telefone (E.164) para SMS/WhatsApp, só no perfil do próprio usuário
 	CreatedAt time.Time `json:"createdAt"`
 }
 
@@ -21,7 +23,45 @@ type UserRequest struct {
 	Password string `json:"password" binding:"required"`
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required"`
-	Role     string `json:"role"`
+}
+
+// UserUpdateRequest para atualização do perfil de um usuário
+type UserUpdateRequest struct {
+	Username string `json:"username" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+}
+
+// PhoneRequest para cadastrar (ou remover, com valor vazio) o telefone do usuário logado
+type PhoneRequest struct {
+	Phone string `json:"phone"`
+}
+
+// UserRoleRequest para alteração do papel de acesso de um usuário
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin leader volunteer"`
+}
+
+// PaginatedData envolve uma página de resultados de uma listagem
+type PaginatedData struct {
+	Items    interface{} `json:"items"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+}
+
+// CalendarFeed contém os endereços dos feeds iCalendar de um usuário
+type CalendarFeed struct {
+	Token     string             `json:"token"`
+	URL       string             `json:"url"`
+	TeamFeeds []TeamCalendarFeed `json:"teamFeeds"`
+}
+
+// TeamCalendarFeed é o feed iCalendar de um time, disponível para o líder do time
+type TeamCalendarFeed struct {
+	TeamID   int    `json:"teamId"`
+	TeamName string `json:"teamName"`
+	URL      string `json:"url"`
 }
 
 // Team representa um time/ministério
@@ -32,6 +72,25 @@ type Team struct {
 	LeaderID    int    `json:"leaderId"`
 }
 
+// TeamReminderSettings são as antecedências (em horas) dos lembretes de escala de um time
+type TeamReminderSettings struct {
+	TeamID    int   `json:"teamId"`
+	LeadHours []int `json:"leadHours"`
+	// Verdadeiro quando o time usa o padrão do servidor (REMINDER_LEAD_HOURS)
+	UsesDefault bool `json:"usesDefault"`
+}
+
+// TeamReminderSettingsRequest para alterar as antecedências dos lembretes (vazio volta ao padrão)
+type TeamReminderSettingsRequest struct {
+	LeadHours []int `json:"leadHours"`
+}
+
+// TeamScope identifica um time cuja liderança é exigida para a operação
+type TeamScope struct {
+	TeamID   int    `json:"teamId"`
+	TeamName string `json:"teamName"`
+}
+
 // TeamRequest para criação/atualização de times
 type TeamRequest struct {
 	Name        string `json:"name" binding:"required"`
@@ -78,9 +137,16 @@ type Event struct {
 	Description string    `json:"description"`
 	Location    string    `json:"location"`
 	EventDate   time.Time `json:"eventDate"`
-	EventType   string    `json:"eventType"`
-	Recurrent   bool      `json:"recurrent"`
-	CreatedAt   time.Time `json:"createdAt"`
+	// EndDate é calculado a partir de EventDate + DurationMinutes
+	DurationMinutes int       `json:"durationMinutes"`
+	EndDate         time.Time `json:"endDate"`
+	EventType       string    `json:"eventType"`
+	Recurrent       bool      `json:"recurrent"`
+	// Campos preenchidos apenas para ocorrências de uma série recorrente
+	SeriesID        *int       `json:"seriesId"`
+	OccurrenceDate  *time.Time `json:"occurrenceDate"`
+	SeriesException bool       `json:"seriesException"`
+	CreatedAt       time.Time  `json:"createdAt"`
 }
 
 // EventRequest para criação/atualização de eventos
@@ -91,6 +157,46 @@ type EventRequest struct {
 	EventDate   time.Time `json:"eventDate" binding:"required"`
 	EventType   string    `json:"eventType" binding:"required"`
 	Recurrent   bool      `json:"recurrent"`
+	// Informe a duração ou o horário de término; sem nenhum dos dois, vale a duração padrão
+	DurationMinutes int        `json:"durationMinutes" binding:"min=0"`
+	EndDate         *time.Time `json:"endDate"`
+}
+
+// EventSeries representa uma série de eventos recorrentes (ex.: cultos de domingo às 10h e 18h)
+type EventSeries struct {
+	ID              int         `json:"id"`
+	Title           string      `json:"title"`
+	Description     string      `json:"description"`
+	Location        string      `json:"location"`
+	EventType       string      `json:"eventType"`
+	RRule           string      `json:"rrule"`
+	StartDate       time.Time   `json:"startDate"`
+	DurationMinutes int         `json:"durationMinutes"`
+	Until           *time.Time  `json:"until"`
+	HorizonDays     int         `json:"horizonDays"`
+	ShiftMinutes    int         `json:"shiftMinutes"`
+	Exdates         []time.Time `json:"exdates"`
+	CreatedAt       time.Time   `json:"createdAt"`
+}
+
+// EventSeriesRequest para criação/atualização de séries de eventos
+type EventSeriesRequest struct {
+	Title           string     `json:"title" binding:"required"`
+	Description     string     `json:"description"`
+	Location        string     `json:"location" binding:"required"`
+	EventType       string     `json:"eventType" binding:"required"`
+	RRule           string     `json:"rrule" binding:"required"`
+	StartDate       time.Time  `json:"startDate" binding:"required"`
+	DurationMinutes int        `json:"durationMinutes" binding:"min=0"` // 0 = duração padrão
+	Until           *time.Time `json:"until"`
+	HorizonDays     int        `json:"horizonDays" binding:"min=0,max=730"` // 0 = padrão de 90 dias
+}
+
+// SeriesBlockedEvent descreve uma ocorrência que impede a exclusão de uma série por ter agendamentos
+type SeriesBlockedEvent struct {
+	EventID       int       `json:"eventId"`
+	EventDate     time.Time `json:"eventDate"`
+	ScheduleCount int       `json:"scheduleCount"`
 }
 
 // Schedule representa um agendamento de voluntário para um evento
@@ -104,6 +210,23 @@ type Schedule struct {
 	CreatedAt        time.Time `json:"createdAt"`
 }
 
+// TraineeProgress resume o acompanhamento de um trainee: serviços já acompanhados por um parceiro
+// experiente, próximos serviços acompanhados e se já atingiu o mínimo para ser graduado
+type TraineeProgress struct {
+	VolunteerID         int        `json:"volunteerId"`
+	UserID              int        `json:"userId"`
+	Name                string     `json:"name"`
+	TeamID              int        `json:"teamId"`
+	TeamName            string     `json:"teamName"`
+	RoleID              int        `json:"roleId"`
+	RoleName            string     `json:"roleName"`
+	ShadowedServices    int        `json:"shadowedServices"`
+	UpcomingShadows     int        `json:"upcomingShadows"`
+	LastShadowedAt      *time.Time `json:"lastShadowedAt"`
+	GraduationThreshold int        `json:"graduationThreshold"`
+	ReadyToGraduate     bool       `json:"readyToGraduate"`
+}
+
 // ScheduleRequest para criação/atualização de agendamentos
 type ScheduleRequest struct {
 	EventID          int    `json:"eventId" binding:"required"`
@@ -111,6 +234,48 @@ type ScheduleRequest struct {
 	Status           string `json:"status"`
 	TraineePartnerID *int   `json:"traineePartnerId"`
 	CreatedByID      int    `json:"createdById" binding:"required"`
+	// Permite agendar mesmo violando regras de disponibilidade (as violações voltam como avisos)
+	IgnoreAvailability bool `json:"ignoreAvailability"`
+}
+
+// StaffingRequirement define quantos voluntários de um papel um evento precisa.
+// Vale para um evento específico (EventID) ou como modelo para um tipo de evento (EventType).
+type StaffingRequirement struct {
+	ID        int     `json:"id"`
+	EventID   *int    `json:"eventId"`
+	EventType *string `json:"eventType"`
+	RoleID    int     `json:"roleId"`
+	Count     int     `json:"count"`
+}
+
+// StaffingRequirementRequest para criação/atualização de necessidades de escala
+type StaffingRequirementRequest struct {
+	EventID   *int    `json:"eventId"`
+	EventType *string `json:"eventType"`
+	RoleID    int     `json:"roleId" binding:"required"`
+	Count     int     `json:"count" binding:"min=0"`
+}
+
+// RoleCoverage compara a necessidade de um papel com os voluntários escalados
+type RoleCoverage struct {
+	RoleID    int    `json:"roleId"`
+	RoleName  string `json:"roleName"`
+	TeamID    int    `json:"teamId"`
+	TeamName  string `json:"teamName"`
+	Required  int    `json:"required"`
+	Scheduled int    `json:"scheduled"`
+	Open      int    `json:"open"`
+}
+
+// EventCoverage resume a cobertura da escala de um evento
+type EventCoverage struct {
+	EventID      int            `json:"eventId"`
+	EventTitle   string         `json:"eventTitle"`
+	EventDate    time.Time      `json:"eventDate"`
+	EventType    string         `json:"eventType"`
+	Roles        []RoleCoverage `json:"roles"`
+	OpenSlots    int            `json:"openSlots"`
+	FullyStaffed bool           `json:"fullyStaffed"`
 }
 
 // AvailabilityRule representa uma regra de disponibilidade para um voluntário
@@ -136,6 +301,75 @@ type AvailabilityRuleRequest struct {
 	EndDate     *time.Time `json:"endDate"`
 }
 
+// AvailabilityViolation descreve uma regra de disponibilidade violada por um agendamento
+type AvailabilityViolation struct {
+	Rule   AvailabilityRule `json:"rule"`
+	Reason string           `json:"reason"`
+}
+
+// ScheduleGenerationRequest para gerar um rascunho de escala para um período
+type ScheduleGenerationRequest struct {
+	StartDate    time.Time `json:"startDate" binding:"required"`
+	EndDate      time.Time `json:"endDate" binding:"required"`
+	TeamIDs      []int     `json:"teamIds" binding:"required,min=1"`
+	LookbackDays int       `json:"lookbackDays"`
+}
+
+// GeneratedAssignment representa um agendamento proposto pelo gerador de escalas
+type GeneratedAssignment struct {
+	EventID           int       `json:"eventId"`
+	EventTitle        string    `json:"eventTitle"`
+	EventDate         time.Time `json:"eventDate"`
+	TeamID            int       `json:"teamId"`
+	RoleID            int       `json:"roleId"`
+	RoleName          string    `json:"roleName"`
+	VolunteerID       int       `json:"volunteerId"`
+	VolunteerName     string    `json:"volunteerName"`
+	IsTrainee         bool      `json:"isTrainee"`
+	TraineePartnerID  *int      `json:"traineePartnerId"`
+	RecentAssignments int       `json:"recentAssignments"`
+}
+
+// UnfilledSlot representa uma vaga que o gerador de escalas não conseguiu preencher
+type UnfilledSlot struct {
+	EventID    int       `json:"eventId"`
+	EventTitle string    `json:"eventTitle"`
+	EventDate  time.Time `json:"eventDate"`
+	TeamID     int       `json:"teamId"`
+	RoleID     int       `json:"roleId"`
+	RoleName   string    `json:"roleName"`
+	Missing    int       `json:"missing"`
+	Reason     string    `json:"reason"`
+}
+
+// ScheduleDraft é o rascunho de escala devolvido para revisão do líder
+type ScheduleDraft struct {
+	Assignments []GeneratedAssignment `json:"assignments"`
+	Unfilled    []UnfilledSlot        `json:"unfilled"`
+}
+
+// DraftAssignment é um agendamento do rascunho aprovado pelo líder
+type DraftAssignment struct {
+	EventID          int  `json:"eventId" binding:"required"`
+	VolunteerID      int  `json:"volunteerId" binding:"required"`
+	TraineePartnerID *int `json:"traineePartnerId"`
+}
+
+// ScheduleDraftCommitRequest para gravar um rascunho de escala
+type ScheduleDraftCommitRequest struct {
+	// Usado apenas sem autenticação (AUTH_MODE=off); com usuário logado, o autor é ele
+	CreatedByID int               `json:"createdById"`
+	Assignments []DraftAssignment `json:"assignments" binding:"required,min=1,dive"`
+}
+
+// DraftIssue descreve um agendamento do rascunho que não pôde ser gravado
+type DraftIssue struct {
+	Index       int    `json:"index"`
+	EventID     int    `json:"eventId"`
+	VolunteerID int    `json:"volunteerId"`
+	Reason      string `json:"reason"`
+}
+
 // SwapRequest representa uma solicitação de troca de horário entre voluntários
 type SwapRequest struct {
 	ID                  int       `json:"id"`
@@ -156,6 +390,61 @@ type SwapRequestRequest struct {
 	Status              string `json:"status"`
 }
 
+// OpenShift é uma solicitação de troca sem alvo, publicada para que outro voluntário do mesmo time e
+// função assuma o turno
+type OpenShift struct {
+	SwapRequestID int       `json:"swapRequestId"`
+	ScheduleID    int       `json:"scheduleId"`
+	EventID       int       `json:"eventId"`
+	EventTitle    string    `json:"eventTitle"`
+	EventDate     time.Time `json:"eventDate"`
+	Location      string    `json:"location"`
+	TeamID        int       `json:"teamId"`
+	TeamName      string    `json:"teamName"`
+	RoleID        int       `json:"roleId"`
+	RoleName      string    `json:"roleName"`
+	RequestorName string    `json:"requestorName"`
+	Reason        string    `json:"reason"`
+	// Voluntário do usuário logado que assumiria o turno
+	VolunteerID int       `json:"volunteerId"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ReplacementSuggestions lista os voluntários que poderiam cobrir um agendamento, do mais indicado ao menos
+// indicado. O VolunteerID de um candidato pode ser enviado como targetVolunteerId da solicitação de troca.
+type ReplacementSuggestions struct {
+	ScheduleID   int                    `json:"scheduleId"`
+	EventID      int                    `json:"eventId"`
+	EventTitle   string                 `json:"eventTitle"`
+	EventDate    time.Time              `json:"eventDate"`
+	TeamID       int                    `json:"teamId"`
+	RoleID       int                    `json:"roleId"`
+	RoleName     string                 `json:"roleName"`
+	LookbackDays int                    `json:"lookbackDays"`
+	Candidates   []ReplacementCandidate `json:"candidates"`
+}
+
+// ReplacementCandidate é um voluntário sugerido como substituto, com a pontuação e os motivos dela
+type ReplacementCandidate struct {
+	VolunteerID int    `json:"volunteerId"`
+	UserID      int    `json:"userId"`
+	Name        string `json:"name"`
+	IsTrainee   bool   `json:"isTrainee"`
+	// Falso quando há conflito de horário ou regra de indisponibilidade
+	Eligible          bool                `json:"eligible"`
+	Score             int                 `json:"score"`
+	RecentAssignments int                 `json:"recentAssignments"`
+	LastAssigned      *time.Time          `json:"lastAssigned"`
+	Reasons           []ReplacementReason `json:"reasons"`
+}
+
+// ReplacementReason explica um fator da pontuação de um candidato
+type ReplacementReason struct {
+	Factor      string `json:"factor"` // availability, conflict, same_day, frequency, recency, trainee
+	Points      int    `json:"points"`
+	Description string `json:"description"`
+}
+
 // Notification representa uma notificação para um usuário
 type Notification struct {
 	ID        int       `json:"id"`
@@ -175,16 +464,85 @@ type NotificationRequest struct {
 	Type    string `json:"type" binding:"required"`
 }
 
+// NotificationPreference indica se um canal externo de notificação (e-mail, por exemplo) está ativo
+type NotificationPreference struct {
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// NotificationPreferenceRequest para ativar/desativar um canal de notificação
+type NotificationPreferenceRequest struct {
+	Channel string `json:"channel" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
 // LoginRequest para autenticação de usuários
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
+// Invitation representa um convite para um novo voluntário entrar em um time com um papel
+type Invitation struct {
+	ID          int        `json:"id"`
+	Email       string     `json:"email"`
+	TeamID      int        `json:"teamId"`
+	TeamName    string     `json:"teamName"`
+	RoleID      int        `json:"roleId"`
+	RoleName    string     `json:"roleName"`
+	IsTrainee   bool       `json:"isTrainee"`
+	InvitedByID *int       `json:"invitedById"`
+	Status      string     `json:"status"` // pending, accepted, expired, revoked
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	AcceptedAt  *time.Time `json:"acceptedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// InvitationRequest para criação de convites
+type InvitationRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	TeamID    int    `json:"teamId" binding:"required"`
+	RoleID    int    `json:"roleId" binding:"required"`
+	IsTrainee bool   `json:"isTrainee"`
+}
+
+// AcceptInvitationRequest para criar a conta a partir de um convite
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	Name     string `json:"name" binding:"required"`
+}
+
+// ChangePasswordRequest para o usuário autenticado trocar a própria senha
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
+// PasswordResetRequest para solicitar um link de redefinição de senha (nome de usuário ou e-mail)
+type PasswordResetRequest struct {
+	Login string `json:"login" binding:"required"`
+}
+
+// PasswordResetConfirmRequest para definir uma nova senha com o token recebido
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
 // LoginResponse para resposta de autenticação
 type LoginResponse struct {
 	Token string `json:"token"`
-	User  User   `json:"user"`
+	// ExpiresAt é o vencimento do token de acesso; use RefreshToken em /api/auth/refresh para renová-lo
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+	User         User      `json:"user"`
+}
+
+// RefreshTokenRequest para renovar o token de acesso ou encerrar a sessão
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
 // DashboardStats para estatísticas do dashboard
@@ -196,11 +554,36 @@ type DashboardStats struct {
 	PendingSwapRequests  int         `json:"pendingSwapRequests"`
 	UnreadNotifications  int         `json:"unreadNotifications"`
 	SchedulingConflicts  int         `json:"schedulingConflicts"`
+	UnderstaffedEvents   int         `json:"understaffedEvents"`
 	VolunteersByTeam     []TeamStat  `json:"volunteersByTeam"`
 	EventsByMonth        []EventStat `json:"eventsByMonth"`
 	RecentNotifications  []Notification `json:"recentNotifications"`
 }
 
+// Conflict agrupa agendamentos de um mesmo usuário cujos horários se sobrepõem
+type Conflict struct {
+	UserID        int             `json:"userId"`
+	VolunteerID   int             `json:"volunteerId"`
+	VolunteerName string          `json:"volunteerName"`
+	EventDay      string          `json:"eventDay"`
+	EventCount    int             `json:"eventCount"`
+	Events        []ConflictEvent `json:"events"`
+}
+
+// ConflictEvent é um dos eventos envolvidos em um conflito de agendamento
+type ConflictEvent struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Location    string    `json:"location"`
+	EventDate   time.Time `json:"eventDate"`
+	EndDate     time.Time `json:"endDate"`
+	ScheduleID  int       `json:"scheduleId"`
+	VolunteerID int       `json:"volunteerId"`
+	TeamID      int       `json:"teamId"`
+	TeamName    string    `json:"teamName"`
+	RoleName    string    `json:"roleName"`
+}
+
 // TeamStat representa estatísticas por time
 type TeamStat struct {
 	TeamName string `json:"teamName"`
@@ -215,8 +598,9 @@ type EventStat struct {
 
 // ApiResponse representa uma resposta padrão da API
 type ApiResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success  bool        `json:"success"`
+	Message  string      `json:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Warnings interface{} `json:"warnings,omitempty"`
 }
\ No newline at end of file
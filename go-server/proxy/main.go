@@ -37,11 +37,23 @@ func main() {
                 *port = envPort
         }
         
+        // Repasse das sessões do Node.js para o Go (desativado sem segredo compartilhado)
+        sessionPath := "/api/session"
+        if envSessionPath := os.Getenv("PROXY_NODE_SESSION_PATH"); envSessionPath != "" {
+                sessionPath = envSessionPath
+        }
+        
+        var sessions *NodeSessionResolver
+        if secret := os.Getenv("PROXY_SESSION_SECRET"); secret != "" {
+                sessions = NewNodeSessionResolver(*nodeJSURL, sessionPath, secret)
+                log.Printf("Repasse de sessões do Node.js habilitado (%s)", sessionPath)
+        }
+        
         // Iniciar o proxy
         log.Printf("Iniciando proxy para Node.js (%s) e Go (%s) na porta %s", *nodeJSURL, *goURL, *port)
         log.Printf("Rotas direcionadas para Go: %v", goRoutes)
         
-        err := StartProxy(*nodeJSURL, *goURL, goRoutes, *port)
+        err := StartProxy(*nodeJSURL, *goURL, goRoutes, *port, sessions)
         if err != nil {
                 log.Fatalf("Erro ao iniciar proxy: %v", err)
         }
@@ -13,14 +13,16 @@ type EndpointProxy struct {
         NodeJSURL string
         GoURL     string
         GoRoutes  []string
+        Sessions  *NodeSessionResolver
 }
 
 // NewEndpointProxy cria um novo proxy para encaminhar solicitações
-func NewEndpointProxy(nodeJSURL, goURL string, goRoutes []string) *EndpointProxy {
+func NewEndpointProxy(nodeJSURL, goURL string, goRoutes []string, sessions *NodeSessionResolver) *EndpointProxy {
         return &EndpointProxy{
                 NodeJSURL: nodeJSURL,
                 GoURL:     goURL,
                 GoRoutes:  goRoutes,
+                Sessions:  sessions,
         }
 }
 
@@ -38,9 +40,15 @@ func (e *EndpointProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
         var targetURL *url.URL
         var err error
         
+        // Cabeçalhos de sessão só podem ser definidos pelo próprio proxy
+        StripSessionHeaders(r)
+        
         if useGoServer {
                 targetURL, err = url.Parse(e.GoURL)
                 log.Printf("Proxy: Encaminhando para Go: %s", r.URL.Path)
+                if e.Sessions != nil {
+                        e.Sessions.Apply(r)
+                }
         } else {
                 targetURL, err = url.Parse(e.NodeJSURL)
                 log.Printf("Proxy: Encaminhando para Node.js: %s", r.URL.Path)
@@ -72,8 +80,8 @@ func (e *EndpointProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // StartProxy inicia o servidor proxy na porta especificada
-func StartProxy(nodeJSURL, goURL string, goRoutes []string, port string) error {
-        proxy := NewEndpointProxy(nodeJSURL, goURL, goRoutes)
+func StartProxy(nodeJSURL, goURL string, goRoutes []string, port string, sessions *NodeSessionResolver) error {
+        proxy := NewEndpointProxy(nodeJSURL, goURL, goRoutes, sessions)
         
         log.Printf("Iniciando proxy na porta %s", port)
         log.Printf("Node.js URL: %s", nodeJSURL)
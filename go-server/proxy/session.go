@@ -0,0 +1,83 @@
+package main
+
+import (
+        "encoding/json"
+        "log"
+        "net/http"
+        "strconv"
+        "strings"
+        "time"
+
+        "volunteer-scheduler/utils"
+)
+
+// NodeSessionResolver consulta o Node.js para identificar a sessão do usuário e repassá-la ao Go
+// em cabeçalhos assinados, enquanto os dois servidores coexistem
+type NodeSessionResolver struct {
+        NodeJSURL   string
+        SessionPath string
+        Secret      string
+        client      *http.Client
+}
+
+// nodeSession representa a resposta do endpoint de sessão do Node.js
+type nodeSession struct {
+        ID   int    `json:"id"`
+        Role string `json:"role"`
+}
+
+// NewNodeSessionResolver cria um resolvedor de sessões do Node.js
+func NewNodeSessionResolver(nodeJSURL, sessionPath, secret string) *NodeSessionResolver {
+        return &NodeSessionResolver{
+                NodeJSURL:   strings.TrimRight(nodeJSURL, "/"),
+                SessionPath: sessionPath,
+                Secret:      secret,
+                client:      &http.Client{Timeout: 2 * time.Second},
+        }
+}
+
+// StripSessionHeaders remove cabeçalhos de sessão enviados pelo cliente, que nunca são confiáveis
+func StripSessionHeaders(r *http.Request) {
+        r.Header.Del(utils.ProxySessionUserIDHeader)
+        r.Header.Del(utils.ProxySessionRoleHeader)
+        r.Header.Del(utils.ProxySessionTimestampHeader)
+        r.Header.Del(utils.ProxySessionSignatureHeader)
+}
+
+// Apply adiciona à requisição destinada ao Go a identidade da sessão do Node.js, se houver.
+// Requisições com JWT próprio são encaminhadas como estão.
+func (n *NodeSessionResolver) Apply(r *http.Request) {
+        if n.Secret == "" || r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") == "" {
+                return
+        }
+
+        req, err := http.NewRequest(http.MethodGet, n.NodeJSURL+n.SessionPath, nil)
+        if err != nil {
+                log.Printf("Erro ao criar consulta de sessão: %v", err)
+                return
+        }
+        req.Header.Set("Cookie", r.Header.Get("Cookie"))
+        req.Header.Set("Accept", "application/json")
+
+        resp, err := n.client.Do(req)
+        if err != nil {
+                log.Printf("Erro ao consultar sessão no Node.js: %v", err)
+                return
+        }
+        defer resp.Body.Close()
+
+        if resp.StatusCode != http.StatusOK {
+                return
+        }
+
+        var session nodeSession
+        if err := json.NewDecoder(resp.Body).Decode(&session); err != nil || session.ID == 0 || session.Role == "" {
+                return
+        }
+
+        timestamp := time.Now().Unix()
+        r.Header.Set(utils.ProxySessionUserIDHeader, strconv.Itoa(session.ID))
+        r.Header.Set(utils.ProxySessionRoleHeader, session.Role)
+        r.Header.Set(utils.ProxySessionTimestampHeader, strconv.FormatInt(timestamp, 10))
+        r.Header.Set(utils.ProxySessionSignatureHeader, utils.SignProxySession(n.Secret, session.ID, session.Role, timestamp))
+}
@@ -1,13 +1,18 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"os"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"volunteer-scheduler/config"
 	"volunteer-scheduler/models"
 )
 
@@ -15,30 +20,36 @@ import (
 type Claims struct {
 	UserID int    `json:"userId"`
 	Role   string `json:"role"`
+	// Sessão (família de tokens de atualização) à qual o token pertence; revogá-la invalida o token
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken cria um novo token JWT para o usuário
-func GenerateToken(user models.User) (string, error) {
+// AccessTokenTTLMinutes é a validade dos tokens de acesso (ACCESS_TOKEN_TTL_MINUTES, definida em main.go)
+var AccessTokenTTLMinutes = 15
+
+// GenerateToken cria um novo token de acesso (JWT de curta duração) para o usuário na sessão informada
+func GenerateToken(user models.User, sessionID string) (string, time.Time, error) {
 	// Tempo de expiração do token
-	expirationHours := 24
-	if os.Getenv("JWT_EXPIRATION_HOURS") != "" {
-		var err error
-		expirationHours, err = strconv.Atoi(os.Getenv("JWT_EXPIRATION_HOURS"))
-		if err != nil {
-			expirationHours = 24
-		}
+	expirationMinutes := AccessTokenTTLMinutes
+	if expirationMinutes <= 0 {
+		expirationMinutes = 15
 	}
+	expiresAt := time.Now().Add(time.Minute * time.Duration(expirationMinutes))
 
-	// Chave secreta para assinatura do token
-	secretKey := getSecretKey()
+	// Chave privada atual (RS256 ou EdDSA)
+	key, err := currentSigningKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
 
 	// Criar claims
 	claims := &Claims{
-		UserID: user.ID,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expirationHours))),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "volunteer-scheduler",
@@ -46,31 +57,23 @@ func GenerateToken(user models.User) (string, error) {
 		},
 	}
 
-	// Criar token com o método de assinatura HS256
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Criar token identificando a chave no cabeçalho kid, para a verificação durante rotações
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
-	// Assinar token com a chave secreta
-	tokenString, err := token.SignedString([]byte(secretKey))
+	// Assinar token com a chave privada
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, expiresAt, nil
 }
 
 // ValidateToken valida um token JWT
 func ValidateToken(tokenString string) (*Claims, error) {
-	// Chave secreta para validação do token
-	secretKey := getSecretKey()
-
-	// Parsear token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verificar que o método de assinatura está correto
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("método de assinatura inválido")
-		}
-		return []byte(secretKey), nil
-	})
+	// Parsear token com a chave pública indicada pelo kid
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -84,36 +87,152 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("token inválido")
 }
 
-// AuthMiddleware middleware para autenticação
-func AuthMiddleware() gin.HandlerFunc {
+// Cabeçalhos com a sessão do Node.js repassada pelo proxy durante a migração.
+// A assinatura (HMAC-SHA256 com o segredo compartilhado) impede que clientes forjem a identidade.
+const (
+	ProxySessionUserIDHeader    = "X-Node-Session-User-Id"
+	ProxySessionRoleHeader      = "X-Node-Session-Role"
+	ProxySessionTimestampHeader = "X-Node-Session-Timestamp"
+	ProxySessionSignatureHeader = "X-Node-Session-Signature"
+)
+
+// proxySessionMaxAge limita a validade de uma sessão assinada pelo proxy
+const proxySessionMaxAge = 5 * time.Minute
+
+// SignProxySession assina a identidade de uma sessão do Node.js repassada pelo proxy
+func SignProxySession(secret string, userID int, role string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(userID) + ":" + role + ":" + strconv.FormatInt(timestamp, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AccessTokenFromQuery aceita o token de acesso no parâmetro access_token quando não há cabeçalho
+// Authorization, para clientes que não enviam cabeçalhos (EventSource do navegador). Deve vir antes de
+// AuthMiddleware e ser usado apenas nas rotas que precisam dele, pois a URL pode aparecer em logs.
+func AccessTokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// AuthMiddleware middleware para autenticação.
+// Aceita um JWT no cabeçalho Authorization ou, se PROXY_SESSION_SECRET estiver configurado, uma sessão
+// do Node.js assinada pelo proxy. No modo de migração, leituras sem identidade ainda são permitidas.
+func AuthMiddleware(cfg config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Obter token do cabeçalho Authorization
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" {
-			c.JSON(401, gin.H{"error": "token não fornecido"})
-			c.Abort()
+		if tokenString := c.GetHeader("Authorization"); tokenString != "" {
+			// Remover "Bearer " do token se presente
+			if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+				tokenString = tokenString[7:]
+			}
+
+			// Validar token
+			claims, err := ValidateToken(tokenString)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+					Success: false,
+					Error:   "Token inválido",
+				})
+				return
+			}
+
+			// Tokens de sessões encerradas (logout, troca de papel, desativação) são recusados
+			active, err := SessionActive(claims.SessionID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiResponse{
+					Success: false,
+					Error:   "Erro ao verificar sessão",
+				})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+					Success: false,
+					Error:   "Sessão encerrada",
+				})
+				return
+			}
+
+			// Adicionar claims ao contexto
+			c.Set("userID", claims.UserID)
+			c.Set("userRole", claims.Role)
+			c.Set("sessionID", claims.SessionID)
+			c.Next()
 			return
 		}
 
-		// Remover "Bearer " do token se presente
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
+		// Sessão do Node.js repassada pelo proxy. Os cabeçalhos assinados só identificam o usuário: desativação
+		// e troca de papel valem imediatamente, como nas sessões do Go
+		if userID, _, ok := proxySession(c, cfg.ProxySessionSecret); ok {
+			role, active, err := UserStatus(userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiResponse{
+					Success: false,
+					Error:   "Erro ao verificar sessão",
+				})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+					Success: false,
+					Error:   "Sessão encerrada",
+				})
+				return
+			}
+
+			c.Set("userID", userID)
+			c.Set("userRole", role)
+			c.Next()
+			return
 		}
 
-		// Validar token
-		claims, err := ValidateToken(tokenString)
-		if err != nil {
-			c.JSON(401, gin.H{"error": "token inválido"})
-			c.Abort()
+		// Durante a migração, leituras anônimas continuam funcionando
+		if cfg.AuthMode == config.AuthModeMigration && isReadOnlyMethod(c.Request.Method) {
+			c.Next()
 			return
 		}
 
-		// Adicionar claims ao contexto
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+			Success: false,
+			Error:   "Não autenticado",
+		})
+	}
+}
+
+// proxySession valida os cabeçalhos de sessão assinados pelo proxy
+func proxySession(c *gin.Context, secret string) (int, string, bool) {
+	if secret == "" || c.GetHeader(ProxySessionSignatureHeader) == "" {
+		return 0, "", false
+	}
 
-		c.Next()
+	userID, err := strconv.Atoi(c.GetHeader(ProxySessionUserIDHeader))
+	if err != nil {
+		return 0, "", false
 	}
+	timestamp, err := strconv.ParseInt(c.GetHeader(ProxySessionTimestampHeader), 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > proxySessionMaxAge || age < -proxySessionMaxAge {
+		return 0, "", false
+	}
+
+	role := c.GetHeader(ProxySessionRoleHeader)
+	expected := SignProxySession(secret, userID, role, timestamp)
+	if !hmac.Equal([]byte(expected), []byte(c.GetHeader(ProxySessionSignatureHeader))) {
+		return 0, "", false
+	}
+
+	return userID, role, true
+}
+
+// isReadOnlyMethod indica métodos HTTP que não alteram dados
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
 }
 
 // IsAdmin middleware para verificar se o usuário é administrador
@@ -121,14 +240,18 @@ func IsAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("userRole")
 		if !exists {
-			c.JSON(401, gin.H{"error": "não autenticado"})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+				Success: false,
+				Error:   "Não autenticado",
+			})
 			return
 		}
 
 		if role != "admin" {
-			c.JSON(403, gin.H{"error": "acesso negado"})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiResponse{
+				Success: false,
+				Error:   "Acesso negado",
+			})
 			return
 		}
 
@@ -141,14 +264,18 @@ func IsAdminOrLeader() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("userRole")
 		if !exists {
-			c.JSON(401, gin.H{"error": "não autenticado"})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiResponse{
+				Success: false,
+				Error:   "Não autenticado",
+			})
 			return
 		}
 
 		if role != "admin" && role != "leader" {
-			c.JSON(403, gin.H{"error": "acesso negado"})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiResponse{
+				Success: false,
+				Error:   "Acesso negado",
+			})
 			return
 		}
 
@@ -156,12 +283,11 @@ func IsAdminOrLeader() gin.HandlerFunc {
 	}
 }
 
-// getSecretKey obtém a chave secreta para JWT
-func getSecretKey() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// Em produção, isso deve ser uma chave segura configurada via variável de ambiente
-		return "volunteer-scheduler-secret-key"
+// GenerateRandomToken gera um token aleatório seguro, codificado em hexadecimal, com o número de bytes informado
+func GenerateRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
 	}
-	return secret
-}
\ No newline at end of file
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// icalDateTime é o formato de data/hora "flutuante" do iCalendar: o horário é exibido como está,
+// no fuso do calendário de quem assina, assim como os horários de parede gravados em events.event_date
+const icalDateTime = "20060102T150405"
+
+// CalendarEvent representa um VEVENT de um feed iCalendar
+type CalendarEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	Status       string // CONFIRMED, TENTATIVE ou CANCELLED
+	LastModified time.Time
+}
+
+// BuildCalendar monta um documento iCalendar (RFC 5545) com os eventos informados
+func BuildCalendar(name string, events []CalendarEvent) string {
+	var b strings.Builder
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Escala MINC//Volunteer Scheduler//PT")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	stamp := time.Now().UTC().Format(icalDateTime + "Z")
+	for _, event := range events {
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+event.UID)
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		writeICalLine(&b, "DTSTART:"+event.Start.Format(icalDateTime))
+		writeICalLine(&b, "DTEND:"+event.End.Format(icalDateTime))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Location != "" {
+			writeICalLine(&b, "LOCATION:"+escapeICalText(event.Location))
+		}
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		if event.Status != "" {
+			writeICalLine(&b, "STATUS:"+event.Status)
+		}
+		if !event.LastModified.IsZero() {
+			writeICalLine(&b, "LAST-MODIFIED:"+event.LastModified.UTC().Format(icalDateTime+"Z"))
+		}
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICalText escapa os caracteres especiais de valores TEXT
+func escapeICalText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// writeICalLine escreve uma linha terminada em CRLF, dobrando-a a cada 75 octetos sem quebrar caracteres UTF-8
+func writeICalLine(b *strings.Builder, line string) {
+	size := 0
+	for _, r := range line {
+		runeSize := len(string(r))
+		if size+runeSize > 75 {
+			b.WriteString("\r\n ")
+			size = 1
+		}
+		b.WriteRune(r)
+		size += runeSize
+	}
+	b.WriteString("\r\n")
+}
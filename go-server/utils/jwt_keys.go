@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"volunteer-scheduler/config"
+)
+
+// jwtKey é uma chave de assinatura/verificação de tokens identificada pelo kid
+type jwtKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer // nil para chaves que apenas verificam
+	Public  crypto.PublicKey
+}
+
+// JSONWebKey representa uma chave pública no formato JWK (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet é o documento publicado em /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	// signingKey assina os novos tokens; verificationKeys aceita também as chaves anteriores (rotação)
+	signingKey       *jwtKey
+	verificationKeys map[string]*jwtKey
+)
+
+// LoadJWTKeys carrega a chave privada de assinatura (RS256 ou EdDSA) e as chaves públicas ainda aceitas
+// na verificação. Sem chave configurada, produção é recusada e em desenvolvimento uma chave Ed25519
+// temporária é gerada (os tokens deixam de valer quando o servidor reinicia).
+func LoadJWTKeys(cfg config.Config) error {
+	keys := make(map[string]*jwtKey)
+	var signer *jwtKey
+
+	if cfg.JWTSigningKeyFile != "" {
+		key, err := readJWTKeyFile(cfg.JWTSigningKeyFile)
+		if err != nil {
+			return err
+		}
+		if key.Private == nil {
+			return fmt.Errorf("%s não contém uma chave privada", cfg.JWTSigningKeyFile)
+		}
+		signer = key
+		keys[key.ID] = key
+	} else if cfg.IsProduction() {
+		return errors.New("JWT_SIGNING_KEY_FILE é obrigatório em produção")
+	} else {
+		_, private, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			return err
+		}
+		signer, err = newJWTKey(private)
+		if err != nil {
+			return err
+		}
+		keys[signer.ID] = signer
+		log.Println("ATENÇÃO: JWT_SIGNING_KEY_FILE não definido; usando chave temporária de desenvolvimento")
+	}
+
+	for _, path := range cfg.JWTVerificationKeyFiles {
+		key, err := readJWTKeyFile(path)
+		if err != nil {
+			return err
+		}
+		// Chaves antigas só verificam; apenas a chave atual assina
+		key.Private = nil
+		if _, exists := keys[key.ID]; !exists {
+			keys[key.ID] = key
+		}
+	}
+
+	jwtKeysMu.Lock()
+	signingKey = signer
+	verificationKeys = keys
+	jwtKeysMu.Unlock()
+
+	log.Printf("Chave de assinatura JWT: %s (%s); %d chave(s) de verificação", signer.ID, signer.Method.Alg(), len(keys))
+	return nil
+}
+
+// JWKS retorna as chaves públicas aceitas na verificação, para que outros serviços validem os tokens
+func JWKS() JSONWebKeySet {
+	jwtKeysMu.RLock()
+	defer jwtKeysMu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	// A chave de assinatura vem primeiro
+	if signingKey != nil {
+		set.Keys = append(set.Keys, signingKey.jwk())
+	}
+	for id, key := range verificationKeys {
+		if signingKey == nil || id != signingKey.ID {
+			set.Keys = append(set.Keys, key.jwk())
+		}
+	}
+	return set
+}
+
+// currentSigningKey retorna a chave usada para assinar novos tokens
+func currentSigningKey() (*jwtKey, error) {
+	jwtKeysMu.RLock()
+	defer jwtKeysMu.RUnlock()
+	if signingKey == nil {
+		return nil, errors.New("chaves JWT não carregadas")
+	}
+	return signingKey, nil
+}
+
+// verificationKey localiza a chave pública pelo kid do cabeçalho do token
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	jwtKeysMu.RLock()
+	key, ok := verificationKeys[kid]
+	jwtKeysMu.RUnlock()
+
+	if !ok {
+		return nil, errors.New("chave de assinatura desconhecida")
+	}
+	// O algoritmo do token precisa ser o da chave (impede a troca de algoritmo pelo cliente)
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("método de assinatura inválido")
+	}
+	return key.Public, nil
+}
+
+// readJWTKeyFile lê uma chave PEM (privada PKCS#1/PKCS#8 ou pública PKIX)
+func readJWTKeyFile(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave JWT %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("chave JWT %s não está no formato PEM", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipo de chave PEM não suportado em %s: %s", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao interpretar chave JWT %s: %v", path, err)
+	}
+
+	key, err := newJWTKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return key, nil
+}
+
+// newJWTKey monta a chave a partir de uma chave RSA ou Ed25519 (privada ou pública)
+func newJWTKey(parsed interface{}) (*jwtKey, error) {
+	key := &jwtKey{}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("apenas chaves RSA e Ed25519 são suportadas")
+	}
+
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("chaves RSA devem ter ao menos 2048 bits")
+	}
+
+	key.ID = key.thumbprint()
+	return key, nil
+}
+
+// jwk converte a chave pública para o formato JWK
+func (k *jwtKey) jwk() JSONWebKey {
+	jwk := JSONWebKey{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// thumbprint calcula o kid como o JWK thumbprint (RFC 7638) da chave pública, estável entre reinícios
+func (k *jwtKey) thumbprint() string {
+	jwk := k.jwk()
+	var members map[string]string
+	if jwk.Kty == "RSA" {
+		members = map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N}
+	} else {
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X}
+	}
+
+	// json.Marshal ordena as chaves do mapa, como a RFC exige
+	canonical, _ := json.Marshal(members)
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"volunteer-scheduler/config"
+)
+
+// LoginAttempts guarda as falhas de login recentes de uma chave (nome de usuário ou IP)
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+	// Próxima tentativa permitida (espera exponencial ou bloqueio temporário)
+	RetryAt     time.Time
+	LockedUntil time.Time
+	// IP da última falha
+	LastIP string
+}
+
+// LoginAttemptStore armazena as tentativas de login. A implementação em memória atende a um
+// único servidor e aos testes; várias instâncias exigiriam um armazenamento compartilhado.
+type LoginAttemptStore interface {
+	Get(key string) (LoginAttempts, bool)
+	Set(key string, attempts LoginAttempts)
+	Delete(key string)
+	// DeleteExpired remove as entradas para as quais expired retorna true e informa quantas removeu
+	DeleteExpired(expired func(LoginAttempts) bool) int
+}
+
+// MemoryLoginAttemptStore é um LoginAttemptStore em memória
+type MemoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]LoginAttempts
+}
+
+// NewMemoryLoginAttemptStore cria um armazenamento de tentativas em memória
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{entries: make(map[string]LoginAttempts)}
+}
+
+// Get implementa LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Get(key string) (LoginAttempts, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.entries[key]
+	return attempts, ok
+}
+
+// Set implementa LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Set(key string, attempts LoginAttempts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = attempts
+}
+
+// Delete implementa LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// DeleteExpired implementa LoginAttemptStore
+func (s *MemoryLoginAttemptStore) DeleteExpired(expired func(LoginAttempts) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for key, attempts := range s.entries {
+		if expired(attempts) {
+			delete(s.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// LoginLimiter aplica espera exponencial entre falhas de login e bloqueio temporário após
+// MaxFailures falhas por nome de usuário (ou IPMaxFailures por IP)
+type LoginLimiter struct {
+	Store           LoginAttemptStore
+	MaxFailures     int
+	IPMaxFailures   int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	// Now permite controlar o relógio nos testes
+	Now func() time.Time
+}
+
+// NewLoginLimiter cria um LoginLimiter com os limites da configuração
+func NewLoginLimiter(cfg config.Config, store LoginAttemptStore) *LoginLimiter {
+	return &LoginLimiter{
+		Store:           store,
+		MaxFailures:     cfg.LoginMaxFailures,
+		IPMaxFailures:   cfg.LoginIPMaxFailures,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutDuration: time.Duration(cfg.LoginLockoutMinutes) * time.Minute,
+		Now:             time.Now,
+	}
+}
+
+// Check retorna quanto tempo falta para que o usuário/IP possa tentar novamente (0 se liberado)
+func (l *LoginLimiter) Check(username, ip string) time.Duration {
+	now := l.Now()
+	var wait time.Duration
+	for _, key := range []string{usernameKey(username), ipKey(ip)} {
+		attempts, ok := l.current(key, now)
+		if !ok {
+			continue
+		}
+		if d := attempts.RetryAt.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// RecordFailure registra uma falha de login. Retorna true (e o fim do bloqueio) quando esta falha
+// bloqueou o nome de usuário, para que o bloqueio seja auditado.
+func (l *LoginLimiter) RecordFailure(username, ip string) (bool, time.Time) {
+	now := l.Now()
+	l.fail(ipKey(ip), l.IPMaxFailures, ip, now)
+	return l.fail(usernameKey(username), l.MaxFailures, ip, now)
+}
+
+// RecordSuccess limpa as falhas do nome de usuário após um login bem-sucedido
+func (l *LoginLimiter) RecordSuccess(username string) {
+	l.Store.Delete(usernameKey(username))
+}
+
+// Unlock remove o bloqueio de um nome de usuário (ação de administrador). O bloqueio é removido apenas do
+// nome de usuário: o IP da última falha continua sujeito ao seu próprio limite, e a espera que ainda resta
+// para ele é devolvida (0 se liberado).
+func (l *LoginLimiter) Unlock(username string) time.Duration {
+	now := l.Now()
+	key := usernameKey(username)
+	attempts, ok := l.current(key, now)
+	l.Store.Delete(key)
+	if !ok || attempts.LastIP == "" {
+		return 0
+	}
+
+	ipAttempts, ok := l.current(ipKey(attempts.LastIP), now)
+	if !ok || !ipAttempts.RetryAt.After(now) {
+		return 0
+	}
+	return ipAttempts.RetryAt.Sub(now)
+}
+
+// IsLocked indica se o nome de usuário está bloqueado e até quando
+func (l *LoginLimiter) IsLocked(username string) (bool, time.Time) {
+	now := l.Now()
+	attempts, ok := l.current(usernameKey(username), now)
+	if !ok || !attempts.LockedUntil.After(now) {
+		return false, time.Time{}
+	}
+	return true, attempts.LockedUntil
+}
+
+// Sweep remove do armazenamento as tentativas expiradas. Sem ele, cada nome de usuário ou IP que falhou uma
+// vez e não voltou a tentar ocuparia memória para sempre; deve ser chamado periodicamente.
+func (l *LoginLimiter) Sweep() int {
+	now := l.Now()
+	return l.Store.DeleteExpired(func(attempts LoginAttempts) bool {
+		return l.expired(attempts, now)
+	})
+}
+
+// fail incrementa as falhas de uma chave e calcula a próxima tentativa permitida
+func (l *LoginLimiter) fail(key string, maxFailures int, ip string, now time.Time) (bool, time.Time) {
+	attempts, _ := l.current(key, now)
+	attempts.Failures++
+	attempts.LastFailure = now
+	attempts.LastIP = ip
+
+	if maxFailures > 0 && attempts.Failures >= maxFailures {
+		// Bloqueio temporário; ao expirar a contagem recomeça
+		alreadyLocked := attempts.LockedUntil.After(now)
+		attempts.LockedUntil = now.Add(l.LockoutDuration)
+		attempts.RetryAt = attempts.LockedUntil
+		l.Store.Set(key, attempts)
+		return !alreadyLocked, attempts.LockedUntil
+	}
+
+	// Espera exponencial: 1s, 2s, 4s... até MaxDelay
+	delay := l.BaseDelay
+	for i := 1; i < attempts.Failures && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	attempts.RetryAt = now.Add(delay)
+	l.Store.Set(key, attempts)
+	return false, time.Time{}
+}
+
+// current retorna as tentativas ainda relevantes de uma chave, descartando as que expiraram
+// (bloqueio encerrado ou nenhuma falha durante o período de bloqueio)
+func (l *LoginLimiter) current(key string, now time.Time) (LoginAttempts, bool) {
+	attempts, ok := l.Store.Get(key)
+	if !ok {
+		return LoginAttempts{}, false
+	}
+
+	if l.expired(attempts, now) {
+		l.Store.Delete(key)
+		return LoginAttempts{}, false
+	}
+	return attempts, true
+}
+
+// expired indica se as tentativas deixaram de valer: bloqueio encerrado ou nenhuma falha durante o
+// período de bloqueio
+func (l *LoginLimiter) expired(attempts LoginAttempts, now time.Time) bool {
+	lockExpired := !attempts.LockedUntil.IsZero() && !attempts.LockedUntil.After(now)
+	stale := now.Sub(attempts.LastFailure) > l.LockoutDuration
+	return lockExpired || stale
+}
+
+// usernameKey normaliza o nome de usuário como chave
+func usernameKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// ipKey monta a chave de um IP
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
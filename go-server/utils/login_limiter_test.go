@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testClock é um relógio controlado pelos testes
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLoginLimiter() (*LoginLimiter, *testClock) {
+	clock := &testClock{now: time.Date(2025, time.January, 5, 10, 0, 0, 0, time.UTC)}
+	limiter := &LoginLimiter{
+		Store:           NewMemoryLoginAttemptStore(),
+		MaxFailures:     5,
+		IPMaxFailures:   20,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		Now:             clock.Now,
+	}
+	return limiter, clock
+}
+
+func TestLoginLimiterBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		wait     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		limiter, _ := newTestLoginLimiter()
+		for i := 0; i < tt.failures; i++ {
+			limiter.RecordFailure("maria", "10.0.0.1")
+		}
+		if got := limiter.Check("maria", "10.0.0.2"); got != tt.wait {
+			t.Errorf("após %d falhas: espera %v, esperado %v", tt.failures, got, tt.wait)
+		}
+	}
+}
+
+func TestLoginLimiterBackoffCapped(t *testing.T) {
+	limiter, _ := newTestLoginLimiter()
+	limiter.MaxFailures = 0
+	for i := 0; i < 10; i++ {
+		limiter.RecordFailure("maria", "10.0.0.1")
+	}
+	if got := limiter.Check("maria", "10.0.0.2"); got != time.Minute {
+		t.Errorf("espera %v, esperado o máximo de %v", got, time.Minute)
+	}
+}
+
+func TestLoginLimiterLockout(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantLocked bool
+	}{
+		{"abaixo do limite", 4, false},
+		{"no limite", 5, true},
+		{"acima do limite", 6, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, clock := newTestLoginLimiter()
+			lockouts := 0
+			for i := 0; i < tt.failures; i++ {
+				if locked, _ := limiter.RecordFailure("Maria ", "10.0.0.1"); locked {
+					lockouts++
+				}
+			}
+
+			locked, until := limiter.IsLocked("maria")
+			if locked != tt.wantLocked {
+				t.Fatalf("IsLocked = %v, esperado %v", locked, tt.wantLocked)
+			}
+			if !tt.wantLocked {
+				return
+			}
+			// O bloqueio só é informado (e auditado) uma vez
+			if lockouts != 1 {
+				t.Errorf("RecordFailure informou %d bloqueios, esperado 1", lockouts)
+			}
+			if want := clock.Now().Add(15 * time.Minute); !until.Equal(want) {
+				t.Errorf("bloqueado até %v, esperado %v", until, want)
+			}
+			if got := limiter.Check("maria", "10.0.0.2"); got != 15*time.Minute {
+				t.Errorf("espera %v, esperado %v", got, 15*time.Minute)
+			}
+		})
+	}
+}
+
+func TestLoginLimiterIPLockout(t *testing.T) {
+	limiter, _ := newTestLoginLimiter()
+	limiter.IPMaxFailures = 3
+	for _, username := range []string{"ana", "bruno", "carla"} {
+		limiter.RecordFailure(username, "10.0.0.1")
+	}
+
+	if got := limiter.Check("daniel", "10.0.0.1"); got != 15*time.Minute {
+		t.Errorf("IP bloqueado: espera %v, esperado %v", got, 15*time.Minute)
+	}
+	if got := limiter.Check("daniel", "10.0.0.2"); got != 0 {
+		t.Errorf("outro IP: espera %v, esperado 0", got)
+	}
+}
+
+func TestLoginLimiterExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		advance  time.Duration
+		wait     time.Duration
+	}{
+		{"espera ainda não cumprida", 3, 3 * time.Second, time.Second},
+		{"espera cumprida", 3, 4 * time.Second, 0},
+		{"bloqueio em andamento", 5, 14 * time.Minute, time.Minute},
+		{"bloqueio encerrado", 5, 15 * time.Minute, 0},
+		{"falhas antigas descartadas", 2, 16 * time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, clock := newTestLoginLimiter()
+			for i := 0; i < tt.failures; i++ {
+				limiter.RecordFailure("maria", "10.0.0.1")
+			}
+			clock.Advance(tt.advance)
+
+			if got := limiter.Check("maria", "10.0.0.2"); got != tt.wait {
+				t.Errorf("espera %v, esperado %v", got, tt.wait)
+			}
+		})
+	}
+}
+
+func TestLoginLimiterCountRestartsAfterLockout(t *testing.T) {
+	limiter, clock := newTestLoginLimiter()
+	for i := 0; i < 5; i++ {
+		limiter.RecordFailure("maria", "10.0.0.1")
+	}
+	clock.Advance(15 * time.Minute)
+
+	limiter.RecordFailure("maria", "10.0.0.1")
+	if locked, _ := limiter.IsLocked("maria"); locked {
+		t.Error("a primeira falha após o bloqueio não deveria bloquear de novo")
+	}
+	if got := limiter.Check("maria", "10.0.0.2"); got != time.Second {
+		t.Errorf("espera %v, esperado %v", got, time.Second)
+	}
+}
+
+func TestLoginLimiterRecordSuccess(t *testing.T) {
+	limiter, _ := newTestLoginLimiter()
+	limiter.RecordFailure("maria", "10.0.0.1")
+	limiter.RecordSuccess("maria")
+
+	if got := limiter.Check("maria", "10.0.0.2"); got != 0 {
+		t.Errorf("espera %v após login bem-sucedido, esperado 0", got)
+	}
+}
+
+func TestLoginLimiterUnlock(t *testing.T) {
+	tests := []struct {
+		name          string
+		ipMaxFailures int
+		wantIPWait    time.Duration
+	}{
+		{"IP em espera", 20, 16 * time.Second},
+		{"IP bloqueado", 5, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, _ := newTestLoginLimiter()
+			limiter.IPMaxFailures = tt.ipMaxFailures
+			for i := 0; i < 5; i++ {
+				limiter.RecordFailure("maria", "10.0.0.1")
+			}
+
+			ipWait := limiter.Unlock("maria")
+			if locked, _ := limiter.IsLocked("maria"); locked {
+				t.Fatal("usuário continua bloqueado após Unlock")
+			}
+			// O desbloqueio vale só para o nome de usuário
+			if got := limiter.Check("maria", "10.0.0.2"); got != 0 {
+				t.Errorf("espera de outro IP %v, esperado 0", got)
+			}
+			if ipWait != tt.wantIPWait {
+				t.Errorf("Unlock devolveu %v, esperado %v", ipWait, tt.wantIPWait)
+			}
+			if got := limiter.Check("maria", "10.0.0.1"); got != tt.wantIPWait {
+				t.Errorf("espera do IP da última falha %v, esperado %v", got, tt.wantIPWait)
+			}
+		})
+	}
+}
+
+func TestLoginLimiterUnlockWithoutFailures(t *testing.T) {
+	limiter, _ := newTestLoginLimiter()
+	if got := limiter.Unlock("maria"); got != 0 {
+		t.Errorf("Unlock sem falhas devolveu %v, esperado 0", got)
+	}
+}
+
+func TestLoginLimiterSweep(t *testing.T) {
+	limiter, clock := newTestLoginLimiter()
+	for i := 0; i < 100; i++ {
+		limiter.RecordFailure(fmt.Sprintf("usuario-%d", i), fmt.Sprintf("10.0.%d.1", i))
+	}
+	// Bloqueado até depois da varredura
+	clock.Advance(10 * time.Minute)
+	for i := 0; i < 5; i++ {
+		limiter.RecordFailure("maria", "10.1.0.1")
+	}
+	clock.Advance(6 * time.Minute)
+
+	if removed := limiter.Sweep(); removed != 200 {
+		t.Errorf("Sweep removeu %d entradas, esperado 200", removed)
+	}
+	if _, ok := limiter.Store.Get(usernameKey("usuario-0")); ok {
+		t.Error("tentativa expirada continua no armazenamento")
+	}
+	if locked, _ := limiter.IsLocked("maria"); !locked {
+		t.Error("Sweep removeu um bloqueio em andamento")
+	}
+	if _, ok := limiter.Store.Get(ipKey("10.1.0.1")); !ok {
+		t.Error("Sweep removeu falhas recentes do IP")
+	}
+}
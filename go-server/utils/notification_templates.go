@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// NotificationEmailData são os dados disponíveis nos modelos de e-mail (e de SMS/WhatsApp) das notificações
+type NotificationEmailData struct {
+	Name    string
+	Title   string
+	Message string
+	// Endereço do frontend para ver as notificações e para desativar os e-mails
+	Link            string
+	PreferencesLink string
+}
+
+// notificationEmailTemplate é o par assunto/corpo de um tipo de notificação
+type notificationEmailTemplate struct {
+	Subject *template.Template
+	Body    *template.Template
+}
+
+// notificationEmailFooter é acrescentado a todos os corpos
+const notificationEmailFooter = `
+
+Para ver os detalhes, acesse: {{.Link}}
+
+Você recebe este e-mail porque ativou as notificações por e-mail. Para desativá-las, acesse: {{.PreferencesLink}}`
+
+// notificationEmailTemplates contém os modelos por notifications.type; tipos sem modelo usam o padrão ("")
+var notificationEmailTemplates = map[string]notificationEmailTemplate{
+	"": newNotificationEmailTemplate("{{.Title}}",
+		"Olá, {{.Name}}!\n\n{{.Message}}"),
+	"swap_request": newNotificationEmailTemplate("Troca de escala: {{.Title}}",
+		"Olá, {{.Name}}!\n\nHá uma atualização em uma solicitação de troca de escala:\n\n{{.Message}}"),
+	"reminder": newNotificationEmailTemplate("Lembrete: {{.Title}}",
+		"Olá, {{.Name}}!\n\nEste é um lembrete da sua escala:\n\n{{.Message}}\n\nSe não puder comparecer, peça uma troca o quanto antes."),
+	"conflict": newNotificationEmailTemplate("Conflito na escala: {{.Title}}",
+		"Olá, {{.Name}}!\n\nEncontramos um conflito na sua escala:\n\n{{.Message}}\n\nProcure o líder do seu time para resolvê-lo."),
+	"trainee": newNotificationEmailTemplate("Acompanhamento de trainee: {{.Title}}",
+		"Olá, {{.Name}}!\n\n{{.Message}}"),
+}
+
+// newNotificationEmailTemplate compila um modelo de assunto e corpo (o corpo recebe o rodapé comum)
+func newNotificationEmailTemplate(subject, body string) notificationEmailTemplate {
+	return notificationEmailTemplate{
+		Subject: template.Must(template.New("subject").Parse(subject)),
+		Body:    template.Must(template.New("body").Parse(body + notificationEmailFooter)),
+	}
+}
+
+// RenderNotificationEmail gera o assunto e o corpo do e-mail de uma notificação conforme o seu tipo
+func RenderNotificationEmail(notificationType string, data NotificationEmailData) (string, string, error) {
+	tmpl, ok := notificationEmailTemplates[notificationType]
+	if !ok {
+		tmpl = notificationEmailTemplates[""]
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.Subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.Body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	// Assuntos não podem ter quebras de linha
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
+
+// notificationTextTemplates contém as mensagens curtas (SMS/WhatsApp) por notifications.type; tipos sem
+// modelo usam o padrão ("")
+var notificationTextTemplates = map[string]*template.Template{
+	"":             newNotificationTextTemplate("{{.Title}}: {{.Message}}"),
+	"swap_request": newNotificationTextTemplate("Troca de escala - {{.Title}}: {{.Message}}"),
+	"reminder":     newNotificationTextTemplate("Lembrete - {{.Title}}: {{.Message}}"),
+	"conflict":     newNotificationTextTemplate("Conflito na escala - {{.Title}}: {{.Message}}"),
+}
+
+// newNotificationTextTemplate compila uma mensagem curta, que sempre termina com o link do aplicativo
+func newNotificationTextTemplate(text string) *template.Template {
+	return template.Must(template.New("text").Parse(text + "\n{{.Link}}"))
+}
+
+// RenderNotificationText gera a mensagem curta (SMS/WhatsApp) de uma notificação conforme o seu tipo
+func RenderNotificationText(notificationType string, data NotificationEmailData) (string, error) {
+	tmpl, ok := notificationTextTemplates[notificationType]
+	if !ok {
+		tmpl = notificationTextTemplates[""]
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
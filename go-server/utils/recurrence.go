@@ -0,0 +1,360 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrencePeriods limita quantos períodos (dias, semanas ou meses) são percorridos ao expandir uma regra
+const maxRecurrencePeriods = 5000
+
+// WeekdayNum representa um item de BYDAY, com ordinal opcional (ex.: 1FR, -1SU)
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule é um subconjunto do RRULE do iCalendar (RFC 5545) usado nas séries de eventos.
+// Suporta FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, BYHOUR, BYMINUTE, COUNT e UNTIL.
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByHour     []int
+	ByMinute   []int
+	Count      int
+	Until      *time.Time
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRecurrenceRule interpreta uma regra no formato "FREQ=WEEKLY;BYDAY=SU;BYHOUR=10,18"
+func ParseRecurrenceRule(value string) (*RecurrenceRule, error) {
+	rule := &RecurrenceRule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("regra de recorrência vazia")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("parte inválida na regra de recorrência: %s", part)
+		}
+		key, val := strings.ToUpper(keyValue[0]), strings.ToUpper(keyValue[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			if val != "DAILY" && val != "WEEKLY" && val != "MONTHLY" {
+				return nil, fmt.Errorf("frequência não suportada: %s", val)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 {
+				return nil, errors.New("INTERVAL deve ser um inteiro positivo")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 {
+				return nil, errors.New("COUNT deve ser um inteiro positivo")
+			}
+		case "UNTIL":
+			until, err := parseRecurrenceUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				weekdayNum, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, weekdayNum)
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31, "BYMONTHDAY")
+		case "BYHOUR":
+			rule.ByHour, err = parseIntList(val, 0, 23, "BYHOUR")
+		case "BYMINUTE":
+			rule.ByMinute, err = parseIntList(val, 0, 59, "BYMINUTE")
+		default:
+			return nil, fmt.Errorf("parâmetro não suportado na regra de recorrência: %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ é obrigatório na regra de recorrência")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT e UNTIL não podem ser usados juntos")
+	}
+	for _, weekdayNum := range rule.ByDay {
+		if weekdayNum.N != 0 && rule.Freq != "MONTHLY" {
+			return nil, errors.New("BYDAY com ordinal só é suportado com FREQ=MONTHLY")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != "MONTHLY" {
+		return nil, errors.New("BYMONTHDAY só é suportado com FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// String serializa a regra de volta para o formato RRULE
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekdayNum := range r.ByDay {
+			code := ""
+			for c, weekday := range weekdayCodes {
+				if weekday == weekdayNum.Weekday {
+					code = c
+				}
+			}
+			if weekdayNum.N != 0 {
+				code = strconv.Itoa(weekdayNum.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByHour) > 0 {
+		parts = append(parts, "BYHOUR="+joinInts(r.ByHour))
+	}
+	if len(r.ByMinute) > 0 {
+		parts = append(parts, "BYMINUTE="+joinInts(r.ByMinute))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between retorna as ocorrências da regra iniciada em dtstart que caem em [from, to).
+// COUNT é contado a partir de dtstart, mesmo para ocorrências anteriores a from. Retorna erro quando
+// chegar a to exigiria percorrer mais de maxRecurrencePeriods períodos.
+func (r *RecurrenceRule) Between(dtstart, from, to time.Time) ([]time.Time, error) {
+	var occurrences []time.Time
+	emitted := 0
+
+	for period := 0; ; period++ {
+		candidates, periodStart := r.periodCandidates(dtstart, period)
+		if !periodStart.Before(to) {
+			return occurrences, nil
+		}
+		if period >= maxRecurrencePeriods {
+			return nil, fmt.Errorf("a regra de recorrência excede o limite de %d períodos", maxRecurrencePeriods)
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return occurrences, nil
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return occurrences, nil
+			}
+			emitted++
+			if !candidate.Before(to) {
+				return occurrences, nil
+			}
+			if !candidate.Before(from) {
+				occurrences = append(occurrences, candidate)
+			}
+		}
+	}
+}
+
+// periodCandidates devolve, em ordem e sem repetições, as ocorrências de um período e o início desse período.
+// Itens sobrepostos da regra (ex.: BYDAY=SU,1SU) geram a mesma ocorrência uma única vez.
+func (r *RecurrenceRule) periodCandidates(dtstart time.Time, period int) ([]time.Time, time.Time) {
+	loc := dtstart.Location()
+	startDay := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, loc)
+	var days []time.Time
+	var periodStart time.Time
+
+	switch r.Freq {
+	case "DAILY":
+		periodStart = startDay.AddDate(0, 0, period*r.Interval)
+		days = []time.Time{periodStart}
+	case "WEEKLY":
+		// Semanas começam na segunda-feira (WKST=MO, padrão da RFC 5545)
+		offset := (int(startDay.Weekday()) + 6) % 7
+		periodStart = startDay.AddDate(0, 0, -offset+period*7*r.Interval)
+		weekdays := []time.Weekday{dtstart.Weekday()}
+		if len(r.ByDay) > 0 {
+			weekdays = nil
+			for _, weekdayNum := range r.ByDay {
+				weekdays = append(weekdays, weekdayNum.Weekday)
+			}
+		}
+		for _, weekday := range weekdays {
+			days = append(days, periodStart.AddDate(0, 0, (int(weekday)+6)%7))
+		}
+	case "MONTHLY":
+		periodStart = time.Date(dtstart.Year(), dtstart.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, loc)
+		days = r.monthDays(periodStart, dtstart.Day())
+	}
+
+	hours := r.ByHour
+	if len(hours) == 0 {
+		hours = []int{dtstart.Hour()}
+	}
+	minutes := r.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{dtstart.Minute()}
+	}
+
+	var candidates []time.Time
+	for _, day := range days {
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				candidates = append(candidates, time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc))
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	unique := candidates[:0]
+	for i, candidate := range candidates {
+		if i == 0 || !candidate.Equal(candidates[i-1]) {
+			unique = append(unique, candidate)
+		}
+	}
+
+	return unique, periodStart
+}
+
+// monthDays calcula os dias de um mês que correspondem a BYMONTHDAY/BYDAY
+func (r *RecurrenceRule) monthDays(monthStart time.Time, defaultDay int) []time.Time {
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = daysInMonth + monthDay + 1
+			}
+			if monthDay >= 1 && monthDay <= daysInMonth {
+				days = append(days, monthStart.AddDate(0, 0, monthDay-1))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, weekdayNum := range r.ByDay {
+			var matches []time.Time
+			for d := 0; d < daysInMonth; d++ {
+				day := monthStart.AddDate(0, 0, d)
+				if day.Weekday() == weekdayNum.Weekday {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case weekdayNum.N == 0:
+				days = append(days, matches...)
+			case weekdayNum.N > 0 && weekdayNum.N <= len(matches):
+				days = append(days, matches[weekdayNum.N-1])
+			case weekdayNum.N < 0 && -weekdayNum.N <= len(matches):
+				days = append(days, matches[len(matches)+weekdayNum.N])
+			}
+		}
+	default:
+		if defaultDay <= daysInMonth {
+			days = append(days, monthStart.AddDate(0, 0, defaultDay-1))
+		}
+	}
+
+	return days
+}
+
+// parseWeekdayNum interpreta um item de BYDAY como "SU", "1FR" ou "-1SU"
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("dia inválido em BYDAY: %s", value)
+	}
+	code := value[len(value)-2:]
+	weekday, ok := weekdayCodes[code]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("dia inválido em BYDAY: %s", value)
+	}
+
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("ordinal inválido em BYDAY: %s", value)
+		}
+	}
+
+	return WeekdayNum{N: n, Weekday: weekday}, nil
+}
+
+// parseRecurrenceUntil aceita UNTIL como data (AAAAMMDD) ou data e hora UTC (AAAAMMDDTHHMMSSZ)
+func parseRecurrenceUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102", value); err == nil {
+		// Uma data sem horário inclui o dia inteiro
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL inválido: %s", value)
+}
+
+// parseIntList interpreta uma lista de inteiros separada por vírgulas dentro de um intervalo
+func parseIntList(value string, min, max int, name string) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < min || n > max || n == 0 && min < 0 {
+			return nil, fmt.Errorf("valor inválido em %s: %s", name, item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// joinInts junta inteiros separados por vírgula
+func joinInts(values []int) string {
+	var parts []string
+	for _, value := range values {
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return strings.Join(parts, ",")
+}
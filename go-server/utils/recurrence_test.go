@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"vazia", ""},
+		{"sem FREQ", "BYDAY=SU"},
+		{"frequência não suportada", "FREQ=YEARLY"},
+		{"INTERVAL inválido", "FREQ=DAILY;INTERVAL=0"},
+		{"COUNT e UNTIL", "FREQ=DAILY;COUNT=3;UNTIL=20250101"},
+		{"ordinal fora do mensal", "FREQ=WEEKLY;BYDAY=1SU"},
+		{"BYMONTHDAY fora do mensal", "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{"dia inválido", "FREQ=WEEKLY;BYDAY=XX"},
+		{"ordinal inválido", "FREQ=MONTHLY;BYDAY=6SU"},
+		{"BYHOUR fora do intervalo", "FREQ=DAILY;BYHOUR=24"},
+		{"BYMONTHDAY zero", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"parâmetro desconhecido", "FREQ=DAILY;BYSETPOS=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRecurrenceRule(tt.value); err == nil {
+				t.Errorf("ParseRecurrenceRule(%q) não retornou erro", tt.value)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleString(t *testing.T) {
+	value := "FREQ=MONTHLY;INTERVAL=2;BYDAY=1SU,-1FR;BYHOUR=10,18;BYMINUTE=30;COUNT=6"
+	rule, err := ParseRecurrenceRule("RRULE:" + value)
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule: %v", err)
+	}
+	if got := rule.String(); got != value {
+		t.Errorf("String() = %q, esperado %q", got, value)
+	}
+}
+
+func TestRecurrenceRuleBetween(t *testing.T) {
+	// 2025-01-05 é um domingo
+	dtstart := date(2025, time.January, 5, 10, 0)
+
+	tests := []struct {
+		name     string
+		rule     string
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name: "semanal com vários dias e horários",
+			rule: "FREQ=WEEKLY;BYDAY=SU,WE;BYHOUR=10,18",
+			from: dtstart,
+			to:   date(2025, time.January, 12, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 5, 10, 0),
+				date(2025, time.January, 5, 18, 0),
+				date(2025, time.January, 8, 10, 0),
+				date(2025, time.January, 8, 18, 0),
+			},
+		},
+		{
+			name: "intervalo de duas semanas",
+			rule: "FREQ=WEEKLY;INTERVAL=2",
+			from: dtstart,
+			to:   date(2025, time.February, 1, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 5, 10, 0),
+				date(2025, time.January, 19, 10, 0),
+			},
+		},
+		{
+			name: "COUNT conta ocorrências anteriores a from",
+			rule: "FREQ=DAILY;COUNT=3",
+			from: date(2025, time.January, 6, 0, 0),
+			to:   date(2025, time.January, 31, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 6, 10, 0),
+				date(2025, time.January, 7, 10, 0),
+			},
+		},
+		{
+			name: "UNTIL como data inclui o dia inteiro",
+			rule: "FREQ=DAILY;UNTIL=20250107",
+			from: dtstart,
+			to:   date(2025, time.January, 31, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 5, 10, 0),
+				date(2025, time.January, 6, 10, 0),
+				date(2025, time.January, 7, 10, 0),
+			},
+		},
+		{
+			name: "último domingo do mês",
+			rule: "FREQ=MONTHLY;BYDAY=-1SU",
+			from: dtstart,
+			to:   date(2025, time.April, 1, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 26, 10, 0),
+				date(2025, time.February, 23, 10, 0),
+				date(2025, time.March, 30, 10, 0),
+			},
+		},
+		{
+			name: "dia 31 pula os meses mais curtos",
+			rule: "FREQ=MONTHLY;BYMONTHDAY=31",
+			from: dtstart,
+			to:   date(2025, time.May, 1, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 31, 10, 0),
+				date(2025, time.March, 31, 10, 0),
+			},
+		},
+		{
+			name: "BYDAY sobreposto não repete ocorrências",
+			rule: "FREQ=MONTHLY;BYDAY=SU,1SU",
+			from: dtstart,
+			to:   date(2025, time.February, 1, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 5, 10, 0),
+				date(2025, time.January, 12, 10, 0),
+				date(2025, time.January, 19, 10, 0),
+				date(2025, time.January, 26, 10, 0),
+			},
+		},
+		{
+			name: "BYDAY sobreposto conta uma vez no COUNT",
+			rule: "FREQ=MONTHLY;BYDAY=1SU,SU;COUNT=2",
+			from: dtstart,
+			to:   date(2025, time.February, 1, 0, 0),
+			want: []time.Time{
+				date(2025, time.January, 5, 10, 0),
+				date(2025, time.January, 12, 10, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q): %v", tt.rule, err)
+			}
+			got, err := rule.Between(dtstart, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Between: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Between = %v, esperado %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("ocorrência %d = %v, esperado %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleBetweenPeriodLimit(t *testing.T) {
+	rule, err := ParseRecurrenceRule("FREQ=DAILY")
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule: %v", err)
+	}
+	dtstart := date(2025, time.January, 5, 10, 0)
+
+	// Dentro do limite: o último período percorrido ainda começa antes de to
+	to := dtstart.AddDate(0, 0, maxRecurrencePeriods-1)
+	if _, err := rule.Between(dtstart, to, to); err != nil {
+		t.Errorf("Between dentro do limite retornou erro: %v", err)
+	}
+
+	// Além do limite: erro em vez de uma lista truncada
+	to = dtstart.AddDate(0, 0, maxRecurrencePeriods+1)
+	if occurrences, err := rule.Between(dtstart, dtstart, to); err == nil {
+		t.Errorf("Between além do limite retornou %d ocorrências sem erro", len(occurrences))
+	}
+
+	// Regras encerradas por COUNT não chegam ao limite
+	rule.Count = 2
+	if _, err := rule.Between(dtstart, dtstart, to); err != nil {
+		t.Errorf("Between com COUNT retornou erro: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"volunteer-scheduler/config"
+)
+
+// Message representa uma mensagem enviada a um usuário (redefinição de senha, convites, avisos)
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender entrega mensagens aos usuários. Implementações reais (SMTP, por exemplo) podem ser
+// trocadas sem alterar os handlers.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// LogSender escreve as mensagens no log do servidor (desenvolvimento)
+type LogSender struct{}
+
+// Send implementa Sender
+func (LogSender) Send(msg Message) error {
+	log.Printf("Mensagem para %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender acrescenta as mensagens a um arquivo local (desenvolvimento e testes)
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send implementa Sender
+func (s *FileSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Data: %s\nPara: %s\nAssunto: %s\n\n%s\n%s\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body, strings.Repeat("-", 72))
+	return err
+}
+
+// NewSender cria o Sender configurado em MAIL_SENDER ("log", "file" ou "smtp")
+func NewSender(cfg config.Config) Sender {
+	switch cfg.MailSender {
+	case "file":
+		return &FileSender{Path: cfg.MailFilePath}
+	case "smtp":
+		return SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	}
+	return LogSender{}
+}
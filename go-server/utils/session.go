@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/jackc/pgx/v4"
+	"volunteer-scheduler/db"
+)
+
+// HashToken retorna o hash SHA-256 de um token opaco; apenas o hash é gravado no banco
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UserStatus retorna o papel atual do usuário e se ele existe e está ativo. Identidades que não passam
+// pelas sessões do Go (sessões do Node.js repassadas pelo proxy) são conferidas por aqui a cada requisição.
+func UserStatus(userID int) (string, bool, error) {
+	var role string
+	var active bool
+	err := db.DB.QueryRow(context.Background(),
+		"SELECT role, active FROM users WHERE id = $1", userID).Scan(&role, &active)
+	if err == pgx.ErrNoRows {
+		return "", false, nil
+	}
+	return role, active, err
+}
+
+// SessionActive indica se a sessão ainda tem um token de atualização válido (não revogado nem expirado)
+func SessionActive(sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+
+	var active bool
+	err := db.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM refresh_tokens
+		 WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > NOW())`, sessionID).Scan(&active)
+	return active, err
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPSender entrega as mensagens por SMTP. Sem usuário configurado não há autenticação, o que basta
+// para servidores locais de teste (MailHog, smtp4dev, etc.).
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	// Remetente no formato "Nome <endereco@dominio>"
+	From string
+}
+
+// Send implementa Sender
+func (s SMTPSender) Send(msg Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("remetente inválido (MAIL_FROM): %v", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("destinatário inválido: %v", err)
+	}
+
+	data, err := buildMailMessage(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, from.Address, []string{to.Address}, data)
+}
+
+// buildMailMessage monta a mensagem em texto puro UTF-8, com o assunto codificado para aceitar acentos
+func buildMailMessage(from, to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	// O codificador converte as quebras de linha para CRLF
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
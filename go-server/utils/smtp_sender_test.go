@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMailMessage(t *testing.T) {
+	from := &mail.Address{Name: "Escala de Voluntários", Address: "escala@example.com"}
+	to := &mail.Address{Name: "João", Address: "joao@example.com"}
+	msg := Message{
+		To:      to.Address,
+		Subject: "Redefinição de senha",
+		Body:    "Olá, João!\nSua escala é às 10h.\n\n" + strings.Repeat("linha longa ", 10),
+	}
+
+	data, err := buildMailMessage(from, to, msg)
+	if err != nil {
+		t.Fatalf("buildMailMessage: %v", err)
+	}
+
+	// Cabeçalhos e corpo só com ASCII, com quebras de linha CRLF
+	for i, b := range data {
+		if b > 127 {
+			t.Fatalf("byte não ASCII na posição %d", i)
+		}
+		if b == '\n' && (i == 0 || data[i-1] != '\r') {
+			t.Fatalf("quebra de linha sem CR na posição %d", i)
+		}
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("assunto inválido: %v", err)
+	}
+	if subject != msg.Subject {
+		t.Errorf("assunto %q, esperado %q", subject, msg.Subject)
+	}
+
+	for header, want := range map[string]*mail.Address{"From": from, "To": to} {
+		got, err := parsed.Header.AddressList(header)
+		if err != nil || len(got) != 1 {
+			t.Fatalf("%s inválido: %v", header, err)
+		}
+		if got[0].Name != want.Name || got[0].Address != want.Address {
+			t.Errorf("%s = %v, esperado %v", header, got[0], want)
+		}
+	}
+
+	if got := parsed.Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+	if got := parsed.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding %q", got)
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("Date inválido: %v", err)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("corpo inválido: %v", err)
+	}
+	if want := strings.ReplaceAll(msg.Body, "\n", "\r\n"); string(body) != want {
+		t.Errorf("corpo %q, esperado %q", body, want)
+	}
+}
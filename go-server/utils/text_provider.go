@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"volunteer-scheduler/config"
+)
+
+// Canais de mensagem de texto
+const (
+	TextChannelSMS      = "sms"
+	TextChannelWhatsApp = "whatsapp"
+)
+
+// Situação de uma mensagem informada pelo provedor no callback de status
+const (
+	TextStatusDelivered = "delivered"
+	TextStatusFailed    = "failed"
+)
+
+// TextMessage é uma mensagem curta enviada por SMS ou WhatsApp
+type TextMessage struct {
+	Channel string
+	// Telefone no formato E.164 (+5511999998888)
+	To   string
+	Body string
+}
+
+// TextStatus é a atualização de uma mensagem recebida no callback de status do provedor
+type TextStatus struct {
+	MessageID string
+	// TextStatusDelivered ou TextStatusFailed
+	Status string
+	Error  string
+}
+
+// TextProvider envia mensagens de texto e devolve o identificador da mensagem no provedor, usado para
+// relacionar os callbacks de status à entrega
+type TextProvider interface {
+	SendText(msg TextMessage) (string, error)
+}
+
+// FakeTextProvider guarda as mensagens em memória e as escreve no log (desenvolvimento e testes)
+type FakeTextProvider struct {
+	mu       sync.Mutex
+	messages []TextMessage
+}
+
+// SendText implementa TextProvider
+func (p *FakeTextProvider) SendText(msg TextMessage) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, msg)
+	id := fmt.Sprintf("fake-%d", len(p.messages))
+	log.Printf("Mensagem %s (%s) para %s: %s", id, msg.Channel, msg.To, msg.Body)
+	return id, nil
+}
+
+// Messages retorna as mensagens enviadas até agora
+func (p *FakeTextProvider) Messages() []TextMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]TextMessage(nil), p.messages...)
+}
+
+// WebhookTextProvider envia as mensagens para uma API HTTP compatível com a Twilio (formulário com To,
+// From e Body; WhatsApp com o prefixo "whatsapp:") ou com a WhatsApp Business Cloud API (JSON)
+type WebhookTextProvider struct {
+	URL string
+	// "twilio" ou "whatsapp_cloud"
+	Format string
+	// Com usuário, autenticação Basic; só com senha, a senha é enviada como token Bearer
+	Username string
+	Password string
+	// Remetentes por canal (no formato "whatsapp_cloud" o remetente já faz parte da URL)
+	SMSFrom      string
+	WhatsAppFrom string
+	// Endereço para o provedor informar a situação das mensagens (apenas no formato "twilio")
+	StatusCallbackURL string
+	Client            *http.Client
+}
+
+// SendText implementa TextProvider
+func (p WebhookTextProvider) SendText(msg TextMessage) (string, error) {
+	if p.URL == "" {
+		return "", fmt.Errorf("TEXT_WEBHOOK_URL não configurada")
+	}
+
+	var req *http.Request
+	var err error
+	switch p.Format {
+	case "whatsapp_cloud":
+		if msg.Channel != TextChannelWhatsApp {
+			return "", fmt.Errorf("o formato whatsapp_cloud não envia mensagens por %s", msg.Channel)
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"messaging_product": "whatsapp",
+			"recipient_type":    "individual",
+			"to":                strings.TrimPrefix(msg.To, "+"),
+			"type":              "text",
+			"text":              map[string]string{"body": msg.Body},
+		})
+		req, err = http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(payload))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	default:
+		to, from := msg.To, p.SMSFrom
+		if msg.Channel == TextChannelWhatsApp {
+			to, from = "whatsapp:"+msg.To, "whatsapp:"+p.WhatsAppFrom
+		}
+		form := url.Values{"To": {to}, "From": {from}, "Body": {msg.Body}}
+		if p.StatusCallbackURL != "" {
+			form.Set("StatusCallback", p.StatusCallbackURL)
+		}
+		req, err = http.NewRequest(http.MethodPost, p.URL, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	} else if p.Password != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Password)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("provedor respondeu %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// Twilio: {"sid": "..."}; WhatsApp Cloud: {"messages": [{"id": "..."}]}
+	var result struct {
+		SID      string `json:"sid"`
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("resposta inválida do provedor: %v", err)
+	}
+	if result.SID != "" {
+		return result.SID, nil
+	}
+	if len(result.Messages) > 0 && result.Messages[0].ID != "" {
+		return result.Messages[0].ID, nil
+	}
+	return "", fmt.Errorf("resposta do provedor sem identificador da mensagem")
+}
+
+// ParseTextStatusCallback lê as atualizações de status enviadas pelo provedor: formulário da Twilio
+// (MessageSid, MessageStatus, ErrorCode) ou JSON de webhook da WhatsApp Cloud API (entry/changes/statuses).
+// Situações intermediárias (enfileirada, enviada) são ignoradas.
+func ParseTextStatusCallback(r *http.Request) ([]TextStatus, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var payload struct {
+			Entry []struct {
+				Changes []struct {
+					Value struct {
+						Statuses []struct {
+							ID     string `json:"id"`
+							Status string `json:"status"`
+							Errors []struct {
+								Code  int    `json:"code"`
+								Title string `json:"title"`
+							} `json:"errors"`
+						} `json:"statuses"`
+					} `json:"value"`
+				} `json:"changes"`
+			} `json:"entry"`
+		}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+			return nil, err
+		}
+
+		var statuses []TextStatus
+		for _, entry := range payload.Entry {
+			for _, change := range entry.Changes {
+				for _, status := range change.Value.Statuses {
+					var detail string
+					if len(status.Errors) > 0 {
+						detail = fmt.Sprintf("%d: %s", status.Errors[0].Code, status.Errors[0].Title)
+					}
+					if normalized := normalizeTextStatus(status.Status); normalized != "" {
+						statuses = append(statuses, TextStatus{MessageID: status.ID, Status: normalized, Error: detail})
+					}
+				}
+			}
+		}
+		return statuses, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	status := normalizeTextStatus(r.PostForm.Get("MessageStatus"))
+	if status == "" || r.PostForm.Get("MessageSid") == "" {
+		return nil, nil
+	}
+	var detail string
+	if code := r.PostForm.Get("ErrorCode"); code != "" {
+		detail = "código " + code
+	}
+	return []TextStatus{{MessageID: r.PostForm.Get("MessageSid"), Status: status, Error: detail}}, nil
+}
+
+// normalizeTextStatus converte a situação do provedor em TextStatusDelivered/TextStatusFailed (ou vazio)
+func normalizeTextStatus(status string) string {
+	switch status {
+	case "delivered", "read":
+		return TextStatusDelivered
+	case "failed", "undelivered":
+		return TextStatusFailed
+	}
+	return ""
+}
+
+// phoneSeparators são os caracteres de formatação aceitos e descartados em números de telefone
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// e164Pattern valida um telefone no formato internacional E.164
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// NormalizePhone remove a formatação do telefone e verifica se está no formato E.164 (com o código do país)
+func NormalizePhone(phone string) (string, bool) {
+	normalized := phoneSeparators.Replace(strings.TrimSpace(phone))
+	return normalized, e164Pattern.MatchString(normalized)
+}
+
+// NewTextProvider cria o TextProvider configurado em TEXT_PROVIDER ("fake" ou "webhook")
+func NewTextProvider(cfg config.Config) TextProvider {
+	if cfg.TextProvider == "webhook" {
+		return WebhookTextProvider{
+			URL:               cfg.TextWebhookURL,
+			Format:            cfg.TextWebhookFormat,
+			Username:          cfg.TextWebhookUsername,
+			Password:          cfg.TextWebhookPassword,
+			SMSFrom:           cfg.TextSMSFrom,
+			WhatsAppFrom:      cfg.TextWhatsAppFrom,
+			StatusCallbackURL: cfg.TextStatusCallbackURL,
+		}
+	}
+	return &FakeTextProvider{}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTextStatusCallbackTwilio(t *testing.T) {
+	tests := []struct {
+		name string
+		form url.Values
+		want []TextStatus
+	}{
+		{
+			name: "entregue",
+			form: url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}},
+			want: []TextStatus{{MessageID: "SM1", Status: TextStatusDelivered}},
+		},
+		{
+			name: "não entregue com código de erro",
+			form: url.Values{"MessageSid": {"SM2"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}},
+			want: []TextStatus{{MessageID: "SM2", Status: TextStatusFailed, Error: "código 30003"}},
+		},
+		{
+			name: "situação intermediária ignorada",
+			form: url.Values{"MessageSid": {"SM3"}, "MessageStatus": {"sent"}},
+		},
+		{
+			name: "sem identificador",
+			form: url.Values{"MessageStatus": {"delivered"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/notifications/deliveries/status",
+				strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			got, err := ParseTextStatusCallback(req)
+			if err != nil {
+				t.Fatalf("ParseTextStatusCallback: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTextStatusCallback = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTextStatusCallbackWhatsAppCloud(t *testing.T) {
+	body := `{
+		"object": "whatsapp_business_account",
+		"entry": [{
+			"id": "123",
+			"changes": [{
+				"field": "messages",
+				"value": {
+					"statuses": [
+						{"id": "wamid.1", "status": "sent"},
+						{"id": "wamid.1", "status": "delivered"},
+						{"id": "wamid.2", "status": "read"},
+						{"id": "wamid.3", "status": "failed", "errors": [{"code": 131026, "title": "Message undeliverable"}]}
+					]
+				}
+			}]
+		}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications/deliveries/status", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	got, err := ParseTextStatusCallback(req)
+	if err != nil {
+		t.Fatalf("ParseTextStatusCallback: %v", err)
+	}
+	want := []TextStatus{
+		{MessageID: "wamid.1", Status: TextStatusDelivered},
+		{MessageID: "wamid.2", Status: TextStatusDelivered},
+		{MessageID: "wamid.3", Status: TextStatusFailed, Error: "131026: Message undeliverable"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTextStatusCallback = %+v, esperado %+v", got, want)
+	}
+}
+
+func TestParseTextStatusCallbackInvalidJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications/deliveries/status", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+
+	if _, err := ParseTextStatusCallback(req); err == nil {
+		t.Error("ParseTextStatusCallback com JSON inválido não retornou erro")
+	}
+}
@@ -1,4 +1,6 @@
 import express, { type Request, Response, NextFunction } from "express";
+import session from "express-session";
+import createMemoryStore from "memorystore";
 import { registerRoutes } from "./routes";
 import { setupVite, serveStatic, log } from "./vite";
 
@@ -6,6 +8,19 @@ const app = express();
 app.use(express.json());
 app.use(express.urlencoded({ extended: false }));
 
+// Login sessions; the Go proxy reads them through GET /api/session
+if (app.get("env") === "production" && !process.env.SESSION_SECRET) {
+  throw new Error("SESSION_SECRET is required in production");
+}
+const MemoryStore = createMemoryStore(session);
+app.use(session({
+  secret: process.env.SESSION_SECRET || "volunteer-scheduler-dev-session",
+  resave: false,
+  saveUninitialized: false,
+  store: new MemoryStore({ checkPeriod: 24 * 60 * 60 * 1000 }),
+  cookie: { httpOnly: true, sameSite: "lax" },
+}));
+
 app.use((req, res, next) => {
   const start = Date.now();
   const path = req.path;
@@ -13,6 +13,12 @@ import {
   insertAvailabilityRuleSchema 
 } from "@shared/schema";
 
+declare module "express-session" {
+  interface SessionData {
+    userId: number;
+  }
+}
+
 export async function registerRoutes(app: Express): Promise<Server> {
   // User related routes
   app.get("/api/users", async (req, res) => {
@@ -399,6 +405,10 @@ export async function registerRoutes(app: Express): Promise<Server> {
         return res.status(401).json({ message: "Invalid username or password" });
       }
       
+      if (user.active === false) {
+        return res.status(403).json({ message: "User is deactivated" });
+      }
+      
       // Create a sanitized user object without the password
       const userResponse = {
         id: user.id,
@@ -409,12 +419,47 @@ export async function registerRoutes(app: Express): Promise<Server> {
         isAdmin: user.role === 'admin'
       };
       
-      res.json(userResponse);
+      // Start a fresh session (avoids session fixation) so the proxy can identify the user to the Go server
+      req.session.regenerate((err) => {
+        if (err) {
+          return res.status(500).json({ message: "Error during authentication" });
+        }
+        req.session.userId = user.id;
+        res.json(userResponse);
+      });
     } catch (error) {
       res.status(500).json({ message: "Error during authentication" });
     }
   });
 
+  // Current session, used by the Go proxy to forward the logged-in user ({ id, role })
+  app.get("/api/session", async (req, res) => {
+    try {
+      const userId = req.session.userId;
+      if (!userId) {
+        return res.status(401).json({ message: "Not authenticated" });
+      }
+      
+      const user = await storage.getUser(userId);
+      if (!user || user.active === false) {
+        return req.session.destroy(() => res.status(401).json({ message: "Not authenticated" }));
+      }
+      
+      res.json({ id: user.id, role: user.role });
+    } catch (error) {
+      res.status(500).json({ message: "Error fetching session" });
+    }
+  });
+
+  app.post("/api/logout", (req, res) => {
+    req.session.destroy((err) => {
+      if (err) {
+        return res.status(500).json({ message: "Error during logout" });
+      }
+      res.status(204).end();
+    });
+  });
+
   // Notification related routes
   app.get("/api/notifications", async (req, res) => {
     try {
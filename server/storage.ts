@@ -156,6 +156,8 @@ export class MemStorage implements IStorage {
       name: "Admin User",
       email: "admin@igreja.org",
       role: "admin",
+      active: true,
+      calendarToken: null,
       createdAt: new Date()
     };
     this.users.set(adminUser.id, adminUser);
@@ -168,6 +170,8 @@ export class MemStorage implements IStorage {
       name: "Líder Silva",
       email: "lider.silva@igreja.org",
       role: "leader",
+      active: true,
+      calendarToken: null,
       createdAt: new Date()
     };
     this.users.set(leaderUser.id, leaderUser);
@@ -267,7 +271,11 @@ export class MemStorage implements IStorage {
     for (const serviceData of upcomingServices) {
       const event: Event = {
         id: this.currentEventId++,
+        durationMinutes: 120,
         ...serviceData,
+        seriesId: null,
+        occurrenceDate: null,
+        seriesException: false,
         createdAt: new Date()
       };
       this.events.set(event.id, event);
@@ -287,7 +295,7 @@ export class MemStorage implements IStorage {
 
   async createUser(insertUser: InsertUser): Promise<User> {
     const id = this.currentUserId++;
-    const user: User = { ...insertUser, id, createdAt: new Date() };
+    const user: User = { ...insertUser, id, active: true, calendarToken: null, createdAt: new Date() };
     this.users.set(id, user);
     return user;
   }
@@ -510,7 +518,15 @@ export class MemStorage implements IStorage {
   
   async createEvent(insertEvent: InsertEvent): Promise<Event> {
     const id = this.currentEventId++;
-    const event: Event = { ...insertEvent, id, createdAt: new Date() };
+    const event: Event = {
+      durationMinutes: 120,
+      ...insertEvent,
+      id,
+      seriesId: null,
+      occurrenceDate: null,
+      seriesException: false,
+      createdAt: new Date()
+    };
     this.events.set(id, event);
     return event;
   }
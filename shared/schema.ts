@@ -1,4 +1,4 @@
-import { pgTable, text, serial, integer, boolean, timestamp } from "drizzle-orm/pg-core";
+import { pgTable, text, serial, integer, boolean, timestamp, unique } from "drizzle-orm/pg-core";
 import { createInsertSchema } from "drizzle-zod";
 import { z } from "zod";
 
@@ -10,6 +10,9 @@ export const users = pgTable("users", {
   name: text("name").notNull(),
   email: text("email").notNull(),
   role: text("role").notNull().default("volunteer"), // admin, leader, volunteer
+  active: boolean("active").notNull().default(true), // false = desativado (login bloqueado)
+  phone: text("phone"), // E.164, used by the SMS/WhatsApp notification channels
+  calendarToken: text("calendar_token").unique(), // secret for the iCalendar feed, null until requested
   createdAt: timestamp("created_at").defaultNow(),
 });
 
@@ -19,6 +22,7 @@ export const teams = pgTable("teams", {
   name: text("name").notNull(),
   description: text("description"),
   leaderId: integer("leader_id").references(() => users.id),
+  reminderLeadHours: integer("reminder_lead_hours").array(), // hours before each event; null = REMINDER_LEAD_HOURS
 });
 
 // Role table (Specific roles within a team like coordinator, vmix, etc.)
@@ -38,6 +42,23 @@ export const volunteers = pgTable("volunteers", {
   isTrainee: boolean("is_trainee").default(false),
 });
 
+// Event series table (Recurring events expanded into concrete events over a rolling horizon)
+export const eventSeries = pgTable("event_series", {
+  id: serial("id").primaryKey(),
+  title: text("title").notNull(),
+  description: text("description"),
+  location: text("location").notNull(),
+  eventType: text("event_type").notNull(),
+  rrule: text("rrule").notNull(), // RRULE subset, e.g. FREQ=WEEKLY;BYDAY=SU;BYHOUR=10,18
+  startDate: timestamp("start_date").notNull(), // DTSTART of the rule
+  durationMinutes: integer("duration_minutes").notNull().default(120),
+  until: timestamp("until"), // null if open-ended
+  horizonDays: integer("horizon_days").notNull().default(90),
+  shiftMinutes: integer("shift_minutes").notNull().default(0), // offset applied to generated occurrences
+  exdates: timestamp("exdates").array(), // occurrences removed from the series
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
 // Event table (Cultos and special events)
 export const events = pgTable("events", {
   id: serial("id").primaryKey(),
@@ -45,8 +66,12 @@ export const events = pgTable("events", {
   description: text("description"),
   location: text("location").notNull(),
   eventDate: timestamp("event_date").notNull(),
+  durationMinutes: integer("duration_minutes").notNull().default(120), // end time = event_date + duration
   eventType: text("event_type").notNull(), // regular_service, special_event
   recurrent: boolean("recurrent").default(false),
+  seriesId: integer("series_id").references(() => eventSeries.id), // null for standalone events
+  occurrenceDate: timestamp("occurrence_date"), // original occurrence of the series rule
+  seriesException: boolean("series_exception").notNull().default(false), // edited individually
   createdAt: timestamp("created_at").defaultNow(),
 });
 
@@ -61,6 +86,15 @@ export const schedules = pgTable("schedules", {
   createdAt: timestamp("created_at").defaultNow(),
 });
 
+// Staffing requirements table (How many volunteers each role needs per event or per event type)
+export const staffingRequirements = pgTable("staffing_requirements", {
+  id: serial("id").primaryKey(),
+  eventId: integer("event_id").references(() => events.id), // null for event type templates
+  eventType: text("event_type"), // null for event-specific requirements
+  roleId: integer("role_id").references(() => roles.id).notNull(),
+  count: integer("count").notNull().default(1),
+});
+
 // Availability rules table (Custom rules for volunteer availability)
 export const availabilityRules = pgTable("availability_rules", {
   id: serial("id").primaryKey(),
@@ -80,7 +114,7 @@ export const swapRequests = pgTable("swap_requests", {
   targetScheduleId: integer("target_schedule_id").references(() => schedules.id),
   targetVolunteerId: integer("target_volunteer_id").references(() => volunteers.id),
   reason: text("reason"),
-  status: text("status").notNull().default("pending"), // pending, approved, rejected
+  status: text("status").notNull().default("pending"), // pending, accepted_by_target, declined_by_target, approved, rejected, cancelled, expired
   createdAt: timestamp("created_at").defaultNow(),
 });
 
@@ -90,18 +124,104 @@ export const notifications = pgTable("notifications", {
   userId: integer("user_id").references(() => users.id).notNull(),
   title: text("title").notNull(),
   message: text("message").notNull(),
-  type: text("type").notNull(), // conflict, swap_request, reminder
+  type: text("type").notNull(), // conflict, swap_request, reminder, trainee
   read: boolean("read").default(false),
   createdAt: timestamp("created_at").defaultNow(),
 });
 
+// Refresh tokens table (rotating login sessions of the Go API; only the token hash is stored)
+export const refreshTokens = pgTable("refresh_tokens", {
+  id: serial("id").primaryKey(),
+  userId: integer("user_id").references(() => users.id).notNull(),
+  sessionId: text("session_id").notNull(), // all tokens rotated from the same login share the session
+  tokenHash: text("token_hash").notNull().unique(),
+  userAgent: text("user_agent"),
+  expiresAt: timestamp("expires_at").notNull(),
+  revokedAt: timestamp("revoked_at"), // set on rotation, logout, role change or deactivation
+  replacedById: integer("replaced_by_id"),
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
+// Password reset tokens table (single-use links; only the token hash is stored)
+export const passwordResetTokens = pgTable("password_reset_tokens", {
+  id: serial("id").primaryKey(),
+  userId: integer("user_id").references(() => users.id).notNull(),
+  tokenHash: text("token_hash").notNull().unique(),
+  expiresAt: timestamp("expires_at").notNull(),
+  usedAt: timestamp("used_at"), // set when used or superseded by a newer link
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
+// Invitations table (onboarding of new volunteers into a team and role; only the token hash is stored)
+export const invitations = pgTable("invitations", {
+  id: serial("id").primaryKey(),
+  email: text("email").notNull(),
+  teamId: integer("team_id").references(() => teams.id).notNull(),
+  roleId: integer("role_id").references(() => roles.id).notNull(),
+  isTrainee: boolean("is_trainee").notNull().default(false),
+  tokenHash: text("token_hash").notNull().unique(),
+  invitedById: integer("invited_by_id").references(() => users.id),
+  expiresAt: timestamp("expires_at").notNull(),
+  acceptedAt: timestamp("accepted_at"),
+  acceptedUserId: integer("accepted_user_id").references(() => users.id),
+  revokedAt: timestamp("revoked_at"),
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
+// Audit logs table (security events such as login lockouts and unlocks)
+export const auditLogs = pgTable("audit_logs", {
+  id: serial("id").primaryKey(),
+  action: text("action").notNull(), // login_lockout, login_unlock, trainee_graduation
+  actorId: integer("actor_id").references(() => users.id), // null for anonymous requests
+  targetUserId: integer("target_user_id").references(() => users.id),
+  ipAddress: text("ip_address"),
+  details: text("details"),
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
+// Notification preferences table (opt-in per user for external channels such as email)
+export const notificationPreferences = pgTable("notification_preferences", {
+  id: serial("id").primaryKey(),
+  userId: integer("user_id").references(() => users.id).notNull(),
+  channel: text("channel").notNull(), // email, sms, whatsapp
+  enabled: boolean("enabled").default(false).notNull(),
+  updatedAt: timestamp("updated_at").defaultNow(),
+}, (table) => [unique().on(table.userId, table.channel)]);
+
+// Notification deliveries table (outbox of notifications to send through external channels, with retries)
+export const notificationDeliveries = pgTable("notification_deliveries", {
+  id: serial("id").primaryKey(),
+  notificationId: integer("notification_id").references(() => notifications.id, { onDelete: "cascade" }).notNull(),
+  userId: integer("user_id").references(() => users.id).notNull(),
+  channel: text("channel").notNull(),
+  status: text("status").default("pending").notNull(), // pending, sent, delivered, failed
+  attempts: integer("attempts").default(0).notNull(),
+  nextAttemptAt: timestamp("next_attempt_at").defaultNow().notNull(),
+  lastError: text("last_error"),
+  sentAt: timestamp("sent_at"),
+  providerMessageId: text("provider_message_id"), // SMS/WhatsApp message id, matched by the status callback
+  deliveredAt: timestamp("delivered_at"),
+  createdAt: timestamp("created_at").defaultNow(),
+});
+
+// Schedule reminders table (reminders already sent, so restarts and other instances don't repeat them)
+export const scheduleReminders = pgTable("schedule_reminders", {
+  id: serial("id").primaryKey(),
+  scheduleId: integer("schedule_id").references(() => schedules.id, { onDelete: "cascade" }).notNull(),
+  leadHours: integer("lead_hours").notNull(),
+  eventDate: timestamp("event_date").notNull(), // event date when sent; a rescheduled event gets new reminders
+  sentAt: timestamp("sent_at").defaultNow(),
+}, (table) => [unique().on(table.scheduleId, table.leadHours, table.eventDate)]);
+
 // Create insert schemas
-export const insertUserSchema = createInsertSchema(users).omit({ id: true, createdAt: true });
+export const insertUserSchema = createInsertSchema(users).omit({ id: true, createdAt: true, active: true, calendarToken: true });
 export const insertTeamSchema = createInsertSchema(teams).omit({ id: true });
 export const insertRoleSchema = createInsertSchema(roles).omit({ id: true });
 export const insertVolunteerSchema = createInsertSchema(volunteers).omit({ id: true });
-export const insertEventSchema = createInsertSchema(events).omit({ id: true, createdAt: true });
+export const insertEventSeriesSchema = createInsertSchema(eventSeries).omit({ id: true, createdAt: true, shiftMinutes: true, exdates: true });
+export const insertEventSchema = createInsertSchema(events).omit({ id: true, createdAt: true, seriesId: true, occurrenceDate: true, seriesException: true });
 export const insertScheduleSchema = createInsertSchema(schedules).omit({ id: true, createdAt: true });
+export const insertStaffingRequirementSchema = createInsertSchema(staffingRequirements).omit({ id: true });
 export const insertAvailabilityRuleSchema = createInsertSchema(availabilityRules).omit({ id: true });
 export const insertSwapRequestSchema = createInsertSchema(swapRequests).omit({ id: true, createdAt: true });
 export const insertNotificationSchema = createInsertSchema(notifications).omit({ id: true, createdAt: true, read: true });
@@ -119,12 +239,18 @@ export type InsertRole = z.infer<typeof insertRoleSchema>;
 export type Volunteer = typeof volunteers.$inferSelect;
 export type InsertVolunteer = z.infer<typeof insertVolunteerSchema>;
 
+export type EventSeries = typeof eventSeries.$inferSelect;
+export type InsertEventSeries = z.infer<typeof insertEventSeriesSchema>;
+
 export type Event = typeof events.$inferSelect;
 export type InsertEvent = z.infer<typeof insertEventSchema>;
 
 export type Schedule = typeof schedules.$inferSelect;
 export type InsertSchedule = z.infer<typeof insertScheduleSchema>;
 
+export type StaffingRequirement = typeof staffingRequirements.$inferSelect;
+export type InsertStaffingRequirement = z.infer<typeof insertStaffingRequirementSchema>;
+
 export type AvailabilityRule = typeof availabilityRules.$inferSelect;
 export type InsertAvailabilityRule = z.infer<typeof insertAvailabilityRuleSchema>;
 
@@ -133,3 +259,17 @@ export type InsertSwapRequest = z.infer<typeof insertSwapRequestSchema>;
 
 export type Notification = typeof notifications.$inferSelect;
 export type InsertNotification = z.infer<typeof insertNotificationSchema>;
+
+export type RefreshToken = typeof refreshTokens.$inferSelect;
+
+export type PasswordResetToken = typeof passwordResetTokens.$inferSelect;
+
+export type Invitation = typeof invitations.$inferSelect;
+
+export type AuditLog = typeof auditLogs.$inferSelect;
+
+export type NotificationPreference = typeof notificationPreferences.$inferSelect;
+
+export type NotificationDelivery = typeof notificationDeliveries.$inferSelect;
+
+export type ScheduleReminder = typeof scheduleReminders.$inferSelect;